@@ -0,0 +1,147 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parseASCIIFormat parses the TFORM value of an ASCII table column:
+// "Aw", "Iw", "Fw.d", "Ew.d" or "Dw.d".
+func parseASCIIFormat(format string) (displayFormat, error) {
+	df, err := parseDisplay(format)
+	if err != nil {
+		return df, err
+	}
+	switch df.code {
+	case "A", "I", "F", "E", "D":
+	default:
+		return df, fmt.Errorf("cfitsio: invalid ASCII table column format %q", format)
+	}
+	if df.w <= 0 || df.e != 0 {
+		return df, fmt.Errorf("cfitsio: invalid ASCII table column format %q", format)
+	}
+	return df, nil
+}
+
+// asciiFormat returns the ASCII table format (TFORM) matching the display
+// format disp for values of type rt, or "" if there is none.
+func asciiFormat(disp string, rt reflect.Type) string {
+	df, err := parseDisplay(disp)
+	if err != nil || df.w <= 0 {
+		return ""
+	}
+	w := strconv.Itoa(df.w)
+	wd := w + "." + strconv.Itoa(df.d)
+	switch rt.Kind() {
+	case reflect.String:
+		if df.code == "A" {
+			return "A" + w
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if df.code == "I" {
+			return "I" + w
+		}
+	case reflect.Float32, reflect.Float64:
+		switch df.code {
+		case "F", "D":
+			return df.code + wd
+		case "E", "ES", "EN", "G":
+			return "E" + wd
+		}
+	}
+	return ""
+}
+
+// asciiLayout returns the starting positions (TBCOL, 1-based) of the columns
+// of an ASCII table and the width of its rows (NAXIS1.)
+// Columns are laid out one after the other, separated by a blank, unless
+// their Start field is set.
+// Columns may not overlap.
+func asciiLayout(cols []Column) ([]int64, int64, error) {
+	tbcol := make([]int64, len(cols))
+	tend := make([]int64, len(cols))
+	rowlen := int64(0)
+	next := int64(1)
+	for i := range cols {
+		col := &cols[i]
+		df, err := parseASCIIFormat(col.Format)
+		if err != nil {
+			return nil, 0, err
+		}
+		beg := next
+		switch {
+		case col.Start > 0:
+			beg = col.Start
+		case col.Start < 0:
+			return nil, 0, fmt.Errorf("cfitsio: invalid start position for column [%s] (%d)", col.Name, col.Start)
+		}
+		end := beg + int64(df.w) - 1
+		for j := 0; j < i; j++ {
+			if beg <= tend[j] && tbcol[j] <= end {
+				return nil, 0, fmt.Errorf(
+					"cfitsio: column [%s] (bytes %d-%d) overlaps column [%s] (bytes %d-%d)",
+					col.Name, beg, end, cols[j].Name, tbcol[j], tend[j],
+				)
+			}
+		}
+		tbcol[i] = beg
+		tend[i] = end
+		next = end + 2
+		if end > rowlen {
+			rowlen = end
+		}
+	}
+	return tbcol, rowlen, nil
+}
+
+// createASCIITable creates a new ASCII table HDU at the end of file f, with
+// the columns described by the ttype, tform and tunit arrays.
+func createASCIITable(f *File, cols []Column, c_types, c_forms, c_units **C.char, c_hduname *C.char) error {
+	tbcol, rowlen, err := asciiLayout(cols)
+	if err != nil {
+		return err
+	}
+	c_tbcol := make([]C.long, len(tbcol))
+	for i, v := range tbcol {
+		c_tbcol[i] = C.long(v)
+	}
+
+	c_status := C.int(0)
+	if len(f.hdus) == 0 {
+		// an ASCII table can not be the primary HDU: create an empty one
+		C.fits_create_img(f.c, 8, 0, nil, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		hdu, err := f.readHDU(0)
+		if err != nil {
+			return err
+		}
+		f.hdus = append(f.hdus, hdu)
+	}
+
+	C.fits_create_hdu(f.c, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	C.fits_write_atblhdr(f.c, C.LONGLONG(rowlen), 0, C.int(len(cols)), c_types, &c_tbcol[0], c_forms, c_units, c_hduname, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// asciiLogical returns whether this Column stores logical values as "T" or
+// "F" strings, as in ASCII tables.
+func (col *Column) asciiLogical() bool {
+	format := strings.TrimSpace(col.Format)
+	return len(format) > 0 && (format[0] == 'A' || format[0] == 'a')
+}
+
+// EOF
@@ -0,0 +1,173 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"bytes"
+	"fmt"
+	"unsafe"
+)
+
+// BitArray is a fixed-size array of bits, as stored in bit (X) columns.
+// Like slices, copies of a BitArray share the same underlying bits.
+type BitArray struct {
+	n    int
+	bits []byte // packed bits, most significant bit first
+}
+
+// NewBitArray returns a BitArray of n bits, all cleared.
+func NewBitArray(n int) BitArray {
+	return BitArray{n: n, bits: make([]byte, (n+7)/8)}
+}
+
+// Len returns the number of bits in the array.
+func (b BitArray) Len() int {
+	return b.n
+}
+
+// Test returns whether the i-th bit is set.
+func (b BitArray) Test(i int) bool {
+	b.check(i)
+	return b.bits[i/8]&(0x80>>uint(i%8)) != 0
+}
+
+// Set sets the i-th bit.
+func (b *BitArray) Set(i int) {
+	b.check(i)
+	b.bits[i/8] |= 0x80 >> uint(i%8)
+}
+
+// Clear clears the i-th bit.
+func (b *BitArray) Clear(i int) {
+	b.check(i)
+	b.bits[i/8] &^= 0x80 >> uint(i%8)
+}
+
+func (b BitArray) check(i int) {
+	if i < 0 || i >= b.n {
+		panic(fmt.Errorf("cfitsio: bit index out of range [%d] with length %d", i, b.n))
+	}
+}
+
+// String returns the bits as a string of 0s and 1s.
+func (b BitArray) String() string {
+	var buf bytes.Buffer
+	for i := 0; i < b.n; i++ {
+		if b.Test(i) {
+			buf.WriteByte('1')
+		} else {
+			buf.WriteByte('0')
+		}
+	}
+	return buf.String()
+}
+
+// readBits reads the cell at column icol and row irow of a bit (X) or
+// logical (L) column.
+// icol and irow are 0-based indices.
+func (col *Column) readBits(f *File, icol int, irow int64) (BitArray, error) {
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_coltype(f.c, c_icol, &c_type, &c_repeat, &c_width, &c_status)
+	if c_status > 0 {
+		return BitArray{}, to_err(c_status)
+	}
+
+	c_len := C.LONGLONG(c_repeat)
+	if c_type < 0 {
+		c_off := C.LONGLONG(0)
+		C.fits_read_descriptll(f.c, c_icol, c_irow, &c_len, &c_off, &c_status)
+		if c_status > 0 {
+			return BitArray{}, to_err(c_status)
+		}
+	}
+
+	bits := NewBitArray(int(c_len))
+	if c_len <= 0 {
+		return bits, nil
+	}
+
+	buf := make([]C.char, int(c_len))
+	c_ptr := unsafe.Pointer(&buf[0])
+	switch TypeCode(c_type) {
+	case TLOGICAL, TVLALOGICAL:
+		c_anynul := C.int(0)
+		C.fits_read_col(f.c, C.TLOGICAL, c_icol, c_irow, 1, c_len, nil, c_ptr, &c_anynul, &c_status)
+	case TBIT, TVLABIT:
+		C.fits_read_col_bit(f.c, c_icol, c_irow, 1, c_len, &buf[0], &c_status)
+	default:
+		return bits, fmt.Errorf("cfitsio: column [%s] is not a bit or logical column", col.Name)
+	}
+	if c_status > 0 {
+		return bits, to_err(c_status)
+	}
+
+	for i, c := range buf {
+		if c != 0 {
+			bits.Set(i)
+		}
+	}
+	return bits, nil
+}
+
+// writeBits writes bits at column icol and row irow of a bit (X) or logical
+// (L) column.
+// icol and irow are 0-based indices.
+func (col *Column) writeBits(f *File, icol int, irow int64, bits BitArray) error {
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_coltype(f.c, c_icol, &c_type, &c_repeat, &c_width, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+
+	switch TypeCode(c_type) {
+	case TBIT, TLOGICAL:
+		if int64(bits.Len()) != int64(c_repeat) {
+			return fmt.Errorf(
+				"cfitsio: column [%s] holds %d bits (got %d)",
+				col.Name, int64(c_repeat), bits.Len(),
+			)
+		}
+	case TVLALOGICAL:
+		// no-op
+	case TVLABIT:
+		return fmt.Errorf("cfitsio: column [%s]: writing variable length bit arrays is not supported", col.Name)
+	default:
+		return fmt.Errorf("cfitsio: column [%s] is not a bit or logical column", col.Name)
+	}
+
+	if bits.Len() == 0 {
+		return nil
+	}
+
+	buf := make([]C.char, bits.Len())
+	for i := range buf {
+		if bits.Test(i) {
+			buf[i] = 1
+		}
+	}
+
+	c_len := C.LONGLONG(len(buf))
+	switch TypeCode(c_type) {
+	case TBIT:
+		C.fits_write_col_bit(f.c, c_icol, c_irow, 1, c_len, &buf[0], &c_status)
+	default:
+		C.fits_write_col(f.c, C.TLOGICAL, c_icol, c_irow, 1, c_len, unsafe.Pointer(&buf[0]), &c_status)
+	}
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// EOF
@@ -0,0 +1,135 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// Eval evaluates the CFITSIO arithmetic expression expr (e.g. "COUNTS / EXPTIME")
+// for each row of the table, and stores the results into dst, a pointer to a
+// slice of numbers or booleans ([]float64, []int64, []bool, ...).
+// Vector expressions yield nelem values per row, stored contiguously.
+// Undefined results are stored as zeros.
+func (hdu *Table) Eval(expr string, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cfitsio: Table.Eval needs a pointer to a slice (got %T)", dst)
+	}
+	rv = rv.Elem()
+
+	c_type, err := colDataType(rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	tcode, nelem, _, err := hdu.testExpr(expr)
+	if err != nil {
+		return err
+	}
+	if tcode == TSTRING {
+		return fmt.Errorf("cfitsio: expression %q yields strings", expr)
+	}
+
+	n := int(hdu.nrows * nelem)
+	slice := reflect.MakeSlice(rv.Type(), n, n)
+	if n > 0 {
+		c_expr := C.CString(expr)
+		defer C.free(unsafe.Pointer(c_expr))
+		c_anynul := C.int(0)
+		c_status := C.int(0)
+		c_ptr := unsafe.Pointer(slice.Index(0).UnsafeAddr())
+		C.fits_calc_rows(hdu.f.c, c_type, c_expr, 1, C.long(n), nil, c_ptr, &c_anynul, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	rv.Set(slice)
+	return nil
+}
+
+// AddComputedColumn evaluates the CFITSIO arithmetic expression expr for each
+// row of the table, and stores the results into the column named name.
+// The column is created if it does not exist yet, with the format tform.
+// If tform is empty, the format is inferred from the type of the expression.
+func (hdu *Table) AddComputedColumn(name, expr, tform string) error {
+	if tform == "" {
+		tcode, nelem, _, err := hdu.testExpr(expr)
+		if err != nil {
+			return err
+		}
+		code := ""
+		switch tcode {
+		case TLOGICAL:
+			code = "L"
+		case TLONG:
+			code = "K"
+		case TDOUBLE:
+			code = "D"
+		case TBIT:
+			code = "X"
+		default:
+			// let CFITSIO decide (e.g. for strings)
+		}
+		if code != "" {
+			tform = strconv.FormatInt(nelem, 10) + code
+		}
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_expr := C.CString(expr)
+	defer C.free(unsafe.Pointer(c_expr))
+	c_name := C.CString(name)
+	defer C.free(unsafe.Pointer(c_name))
+	c_tform := C.CString(tform)
+	defer C.free(unsafe.Pointer(c_tform))
+	c_status := C.int(0)
+	C.fits_calculator(hdu.f.c, c_expr, hdu.f.c, c_name, c_tform, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return hdu.refresh()
+}
+
+// testExpr returns the data type, the number of elements per row and the
+// dimensions of the result of the CFITSIO expression expr.
+func (hdu *Table) testExpr(expr string) (TypeCode, int64, []int64, error) {
+	err := hdu.seekHDU()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	const maxdim = 5
+	c_expr := C.CString(expr)
+	defer C.free(unsafe.Pointer(c_expr))
+	c_type := C.int(0)
+	c_nelem := C.long(0)
+	c_naxis := C.int(0)
+	c_naxes := make([]C.long, maxdim)
+	c_status := C.int(0)
+	C.fits_test_expr(hdu.f.c, c_expr, maxdim, &c_type, &c_nelem, &c_naxis, &c_naxes[0], &c_status)
+	if c_status > 0 {
+		return 0, 0, nil, to_err(c_status)
+	}
+
+	nelem := int64(c_nelem)
+	if nelem < 0 {
+		// constant expression
+		nelem = -nelem
+	}
+	dims := make([]int64, int(c_naxis))
+	for i := range dims {
+		dims[i] = int64(c_naxes[i])
+	}
+	return TypeCode(c_type), nelem, dims, nil
+}
+
+// EOF
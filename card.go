@@ -54,7 +54,15 @@ func newCard(f *File, i int) (Card, error) {
 
 	keyclass := C.fits_get_keyclass(c_key)
 	switch keyclass {
-	case C.TYP_COMM_KEY, C.TYP_CONT_KEY:
+	case C.TYP_COMM_KEY:
+		if name == "COMMENT" || name == "HISTORY" {
+			// the text of commentary records is returned as their comment
+			card.Name = name
+			card.Value = comment
+			return card, nil
+		}
+		return card, fmt.Errorf("comm key")
+	case C.TYP_CONT_KEY:
 		return card, fmt.Errorf("comm key | continue key")
 	}
 
@@ -98,7 +106,13 @@ func parseRecord(name, value, comment string, card *Card) error {
 		var vv int64
 		vv, err = strconv.ParseInt(value, 10, 64)
 		if err != nil {
-			return err
+			// e.g. TZERO=9223372036854775808 for unsigned 64b columns
+			uv, uerr := strconv.ParseUint(value, 10, 64)
+			if uerr != nil {
+				return err
+			}
+			card.Value = uv
+			return nil
 		}
 		card.Value = vv
 
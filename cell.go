@@ -0,0 +1,89 @@
+package cfitsio
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Get reads the value of the cell at row (0-based) of the column named col
+// into dst, a pointer to a value of the type of the column (e.g. *float64,
+// *[3]int32 or *[]float32 for vector and variable length array columns.)
+func (hdu *Table) Get(row int64, col string, dst interface{}) error {
+	icol := hdu.Index(col)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", col)
+	}
+	if row < 0 || row >= hdu.nrows {
+		return fmt.Errorf("cfitsio: invalid row index (%d) (nrows=%d)", row, hdu.nrows)
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cfitsio: Table.Get needs a non-nil pointer (got %T)", dst)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	return hdu.cols[icol].read(hdu.f, icol, row, dst)
+}
+
+// Set overwrites the value of the cell at row (0-based) of the column named
+// col with v (or with the value v points to).
+// A nil v, a nil pointer or an invalid Null{Float64,Int64,String,Bool} value
+// makes the cell undefined.
+func (hdu *Table) Set(row int64, col string, v interface{}) error {
+	icol := hdu.Index(col)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", col)
+	}
+	if row < 0 || row >= hdu.nrows {
+		return fmt.Errorf("cfitsio: invalid row index (%d) (nrows=%d)", row, hdu.nrows)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	c := &hdu.cols[icol]
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return c.writeUndefined(hdu.f, icol, row)
+	}
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	c.Value = rv.Interface()
+	return c.write(hdu.f, icol, row, c.Value)
+}
+
+// WriteRow overwrites the row at index row (0-based) with the values held by
+// v, a pointer to a struct or to a map[string]interface{}, as for Write.
+// Columns without a corresponding field or key are left untouched.
+// row == NumRows() appends a new row.
+func (hdu *Table) WriteRow(row int64, v interface{}) error {
+	if row < 0 || row > hdu.nrows {
+		return fmt.Errorf("cfitsio: invalid row index (%d) (nrows=%d)", row, hdu.nrows)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.updateNumRows()
+
+	switch v := v.(type) {
+	case *map[string]interface{}:
+		return hdu.writeMap(row, *v)
+	case map[string]interface{}:
+		return hdu.writeMap(row, v)
+	}
+
+	rt := reflect.TypeOf(v)
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct || isCellType(rt.Elem()) {
+		return fmt.Errorf("cfitsio: Table.WriteRow needs a pointer to a struct or a map (got %T)", v)
+	}
+	return hdu.writeStruct(row, v)
+}
+
+// EOF
@@ -0,0 +1,305 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// AddColumn inserts the column col before the column at (0-based).
+// at == NumCols() (or at < 0) appends the column after the last one.
+// The format of the column is inferred from its Value if empty, as for NewTable.
+// The cells of the new column are blank.
+func (hdu *Table) AddColumn(col Column, at int) error {
+	if at < 0 {
+		at = len(hdu.cols)
+	}
+	if at > len(hdu.cols) {
+		return fmt.Errorf("cfitsio: invalid column index (%d)", at)
+	}
+	if hdu.Index(col.Name) >= 0 {
+		return fmt.Errorf("cfitsio: column [%s] already exists", col.Name)
+	}
+
+	htype := hdu.Type()
+	err := col.inferFormat(htype)
+	if err != nil {
+		return err
+	}
+	err = col.checkNull(htype)
+	if err != nil {
+		return err
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_name := C.CString(col.Name)
+	defer C.free(unsafe.Pointer(c_name))
+	c_form := C.CString(col.Format)
+	defer C.free(unsafe.Pointer(c_form))
+	c_status := C.int(0)
+	C.fits_insert_col(hdu.f.c, C.int(at+1), c_name, c_form, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+
+	if col.Unit != "" {
+		err = writeCard(hdu.f, &Card{Name: fmt.Sprintf("TUNIT%d", at+1), Value: col.Unit})
+		if err != nil {
+			return err
+		}
+	}
+	err = col.writeKeys(hdu.f, at, htype)
+	if err != nil {
+		return err
+	}
+	return hdu.refresh()
+}
+
+// DeleteColumn deletes the column named n.
+func (hdu *Table) DeleteColumn(n string) error {
+	icol := hdu.Index(n)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", n)
+	}
+	if len(hdu.cols) == 1 {
+		return fmt.Errorf("cfitsio: can not delete the last column [%s]", n)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_status := C.int(0)
+	C.fits_delete_col(hdu.f.c, C.int(icol+1), &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return hdu.refresh()
+}
+
+// RenameColumn renames the column named old into name.
+func (hdu *Table) RenameColumn(old, name string) error {
+	icol := hdu.Index(old)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", old)
+	}
+	if hdu.Index(name) >= 0 {
+		return fmt.Errorf("cfitsio: column [%s] already exists", name)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	err = hdu.setColumnName(icol, name)
+	if err != nil {
+		return err
+	}
+	return hdu.refresh()
+}
+
+// ModifyColumn changes the format (TFORM) of the column named n.
+//
+// If only the repeat count changes (e.g. from "3E" to "5E"), the vector
+// length of the column is modified in place: values are truncated or padded
+// with blanks.
+// The dimensions (TDIM) of the column are then updated if the new repeat
+// count is a multiple of the size of all but its last dimension, and removed
+// otherwise.
+// If the type changes, the values of the column are converted to the new
+// type (e.g. from "1J" to "1D"), which requires the same repeat count.
+// The unit of the column is kept, but not its other keywords (TNULL, TSCAL,
+// TZERO, TDISP, TDIM.)
+func (hdu *Table) ModifyColumn(n, format string) error {
+	icol := hdu.Index(n)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", n)
+	}
+
+	orepeat, ocode, err := parseTForm(hdu.cols[icol].Format)
+	if err != nil {
+		return err
+	}
+	nrepeat, ncode, err := parseTForm(format)
+	if err != nil {
+		return err
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_status := C.int(0)
+	switch {
+	case ocode == ncode && orepeat == nrepeat:
+		return nil
+
+	case ocode == ncode:
+		C.fits_modify_vector_len(hdu.f.c, C.int(icol+1), C.LONGLONG(nrepeat), &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		err = hdu.resizeDim(icol, nrepeat)
+		if err != nil {
+			return err
+		}
+
+	default:
+		// insert a new column after the old one, copy (and convert) the
+		// values, then replace the old column with the new one.
+		c_name := C.CString(n + "_tmp")
+		defer C.free(unsafe.Pointer(c_name))
+		c_form := C.CString(format)
+		defer C.free(unsafe.Pointer(c_form))
+		C.fits_insert_col(hdu.f.c, C.int(icol+2), c_name, c_form, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		if hdu.nrows > 0 {
+			C.fits_copy_col(hdu.f.c, hdu.f.c, C.int(icol+1), C.int(icol+2), 0, &c_status)
+			if c_status > 0 {
+				err = to_err(c_status)
+				c_status = 0
+				C.fits_delete_col(hdu.f.c, C.int(icol+2), &c_status)
+				return err
+			}
+		}
+		C.fits_delete_col(hdu.f.c, C.int(icol+1), &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		err = hdu.setColumnName(icol, n)
+		if err != nil {
+			return err
+		}
+		if unit := hdu.cols[icol].Unit; unit != "" {
+			err = writeCard(hdu.f, &Card{Name: fmt.Sprintf("TUNIT%d", icol+1), Value: unit})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return hdu.refresh()
+}
+
+// resizeDim updates the TDIM keyword of column icol (0-based) of the current
+// HDU after its repeat count was changed to repeat.
+func (hdu *Table) resizeDim(icol int, repeat int64) error {
+	dim := hdu.cols[icol].Dim
+	if len(dim) == 0 {
+		return nil
+	}
+
+	size := int64(1)
+	for _, n := range dim[:len(dim)-1] {
+		size *= n
+	}
+	c_status := C.int(0)
+	if size > 0 && repeat%size == 0 {
+		c_naxes := make([]C.long, len(dim))
+		for i, n := range dim {
+			c_naxes[i] = C.long(n)
+		}
+		c_naxes[len(dim)-1] = C.long(repeat / size)
+		C.fits_write_tdim(hdu.f.c, C.int(icol+1), C.int(len(c_naxes)), &c_naxes[0], &c_status)
+	} else {
+		c_key := C.CString(fmt.Sprintf("TDIM%d", icol+1))
+		defer C.free(unsafe.Pointer(c_key))
+		C.fits_delete_key(hdu.f.c, c_key, &c_status)
+	}
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// setColumnName sets the TTYPE keyword of column icol (0-based) of the
+// current HDU, keeping its comment.
+func (hdu *Table) setColumnName(icol int, name string) error {
+	key := fmt.Sprintf("TTYPE%d", icol+1)
+	card := Card{Name: key, Value: name}
+	if old := hdu.header.Get(key); old != nil {
+		card.Comment = old.Comment
+	}
+	err := writeCard(hdu.f, &card)
+	if err != nil {
+		return err
+	}
+
+	// make CFITSIO aware of the new column name
+	c_status := C.int(0)
+	C.fits_set_hdustruc(hdu.f.c, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// refresh re-reads the header and the description of the columns of this
+// table, after a modification of its structure.
+// Rows iterators opened on this table are invalidated.
+func (hdu *Table) refresh() error {
+	raw := make([]string, 0)
+	for _, col := range hdu.cols {
+		if col.raw {
+			raw = append(raw, col.Name)
+		}
+	}
+
+	v, err := hdu.f.readHDU(int(hdu.id) - 1) // 1-based to 0-based index
+	if err != nil {
+		return err
+	}
+	tbl := v.(*Table)
+	hdu.header = tbl.header
+	hdu.nrows = tbl.nrows
+	hdu.cols = tbl.cols
+	hdu.gen++
+
+	for _, n := range raw {
+		if hdu.Index(n) < 0 {
+			continue
+		}
+		err = hdu.SetRawMode(true, n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTForm returns the repeat count and the data type code of a TFORM
+// value, e.g. (3, "E") for "3E" or (1, "PD(12)") for "PD(12)".
+func parseTForm(format string) (int64, string, error) {
+	format = strings.TrimSpace(format)
+	i := 0
+	for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+		i++
+	}
+	if i == len(format) {
+		return 0, "", fmt.Errorf("cfitsio: invalid column format %q", format)
+	}
+	repeat := int64(1)
+	if i > 0 {
+		v, err := strconv.ParseInt(format[:i], 10, 64)
+		if err != nil {
+			return 0, "", err
+		}
+		repeat = v
+	}
+	code := strings.ToUpper(format[i:])
+	return repeat, code, nil
+}
+
+// EOF
@@ -0,0 +1,495 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// ReadColumn reads the values of the column named n over the rows [beg, end)
+// into dst, a pointer to a slice.
+//
+// dst may be a pointer to:
+//   - a slice of scalars ([]float64, []int32, []string, ...), with one value
+//     per row (or, for vector columns, repeat values per row, and for string
+//     columns made of several fixed-width strings, e.g. "20A10", one string
+//     per sub-string),
+//   - a slice of slices ([][]float32, ...), with one slice per row, for
+//     vector and variable length array columns,
+//   - a slice of (nested) arrays ([][3][4]float32, ...), with one array per
+//     row, for vector columns.
+//
+// The slice is resized to hold all the requested values.
+//
+// Values are read with a single call to CFITSIO per chunk of rows (as many
+// rows as fit in the CFITSIO buffers), which is much faster than iterating
+// with Rows.Scan.
+// If end > NumRows(), the read stops at NumRows().
+func (hdu *Table) ReadColumn(n string, beg, end int64, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cfitsio: ReadColumn needs a pointer to a slice (got %T)", dst)
+	}
+	rv = rv.Elem()
+
+	icol := hdu.Index(n)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", n)
+	}
+	return hdu.readColumn(icol, beg, end, rv)
+}
+
+// readColumn reads the values of column icol (0-based) over the rows
+// [beg, end) into the slice rv.
+func (hdu *Table) readColumn(icol int, beg, end int64, rv reflect.Value) error {
+	n := hdu.cols[icol].Name
+	if end > hdu.nrows {
+		end = hdu.nrows
+	}
+	if beg < 0 {
+		beg = 0
+	}
+	nrows := end - beg
+	if nrows < 0 {
+		nrows = 0
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	tcode, repeat, width, err := hdu.colType(icol)
+	if err != nil {
+		return err
+	}
+
+	rt := rv.Type().Elem()
+	switch {
+	case tcode < 0:
+		if rt.Kind() != reflect.Slice {
+			return fmt.Errorf("cfitsio: column [%s] is a variable length array (got %v)", n, rv.Type())
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), int(nrows), int(nrows)))
+		for i := 0; i < int(nrows); i++ {
+			err = hdu.readVLA(icol, beg+int64(i), rv.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+
+	case rt.Kind() == reflect.String:
+		nsub := stringsPerRow(repeat, width)
+		rv.Set(reflect.MakeSlice(rv.Type(), int(nrows*nsub), int(nrows*nsub)))
+		err = hdu.readStrings(icol, beg, width, nsub, rv)
+
+	case rt.Kind() == reflect.Bool && hdu.cols[icol].asciiLogical():
+		// logical values of ASCII tables are stored as "T" or "F"
+		strs := make([]string, int(nrows))
+		err = hdu.readStrings(icol, beg, width, 1, reflect.ValueOf(strs))
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), int(nrows), int(nrows)))
+		for i, str := range strs {
+			rv.Index(i).SetBool(str == "T")
+		}
+
+	case rt.Kind() == reflect.Slice:
+		flat := reflect.MakeSlice(rt, int(nrows*repeat), int(nrows*repeat))
+		err = hdu.readFlat(icol, beg, nrows, repeat, flat)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), int(nrows), int(nrows)))
+		for i := 0; i < int(nrows); i++ {
+			lo := i * int(repeat)
+			hi := lo + int(repeat)
+			rv.Index(i).Set(flat.Slice3(lo, hi, hi))
+		}
+
+	default:
+		// scalars or (nested) arrays
+		dims, _ := arrayShape(rt)
+		if repeat%nelements(dims) != 0 {
+			return fmt.Errorf("cfitsio: column [%s] can not be read into %v", n, rv.Type())
+		}
+		nvals := int(nrows * repeat / nelements(dims))
+		rv.Set(reflect.MakeSlice(rv.Type(), nvals, nvals))
+		err = hdu.readFlat(icol, beg, nrows, repeat, rv)
+	}
+	return err
+}
+
+// WriteColumn writes the values held by src into the column named n,
+// starting at row beg.
+// src is a slice (or a pointer to a slice) with the same layout than for
+// ReadColumn: scalars (one value per row, or repeat values per row for
+// vector columns) or slices (one slice per row).
+// Rows past the end of the table are appended.
+func (hdu *Table) WriteColumn(n string, beg int64, src interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(src))
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("cfitsio: WriteColumn needs a slice (got %T)", src)
+	}
+
+	icol := hdu.Index(n)
+	if icol < 0 {
+		return fmt.Errorf("cfitsio: no column named [%s]", n)
+	}
+	return hdu.writeColumn(icol, beg, rv)
+}
+
+// writeColumn writes the values held by the slice rv into the column icol
+// (0-based), starting at row beg.
+func (hdu *Table) writeColumn(icol int, beg int64, rv reflect.Value) error {
+	n := hdu.cols[icol].Name
+	if beg < 0 {
+		return fmt.Errorf("cfitsio: invalid row index (%d)", beg)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.updateNumRows()
+
+	tcode, repeat, width, err := hdu.colType(icol)
+	if err != nil {
+		return err
+	}
+
+	rt := rv.Type().Elem()
+	switch {
+	case tcode < 0:
+		if rt.Kind() != reflect.Slice {
+			return fmt.Errorf("cfitsio: column [%s] is a variable length array (got %v)", n, rv.Type())
+		}
+		for i := 0; i < rv.Len(); i++ {
+			row := rv.Index(i)
+			if row.Len() == 0 {
+				continue
+			}
+			err = hdu.writeFlat(icol, beg+int64(i), 1, int64(row.Len()), row)
+			if err != nil {
+				return err
+			}
+		}
+		return err
+
+	case rt.Kind() == reflect.String:
+		nsub := stringsPerRow(repeat, width)
+		if int64(rv.Len())%nsub != 0 {
+			return fmt.Errorf(
+				"cfitsio: column [%s] holds %d strings per row (got %d strings)",
+				n, nsub, rv.Len(),
+			)
+		}
+		return hdu.writeStrings(icol, beg, nsub, rv)
+
+	case rt.Kind() == reflect.Bool && hdu.cols[icol].asciiLogical():
+		// logical values of ASCII tables are stored as "T" or "F"
+		strs := make([]string, rv.Len())
+		for i := range strs {
+			strs[i] = "F"
+			if rv.Index(i).Bool() {
+				strs[i] = "T"
+			}
+		}
+		return hdu.writeStrings(icol, beg, 1, reflect.ValueOf(strs))
+
+	case rt.Kind() == reflect.Slice:
+		nrows := int64(rv.Len())
+		flat := reflect.MakeSlice(rt, int(nrows*repeat), int(nrows*repeat))
+		for i := 0; i < rv.Len(); i++ {
+			row := rv.Index(i)
+			if int64(row.Len()) != repeat {
+				return fmt.Errorf(
+					"cfitsio: invalid number of values for row %d of column [%s] (got %d. expected %d)",
+					beg+int64(i), n, row.Len(), repeat,
+				)
+			}
+			reflect.Copy(flat.Slice(i*int(repeat), (i+1)*int(repeat)), row)
+		}
+		return hdu.writeFlat(icol, beg, nrows, repeat, flat)
+
+	default:
+		// scalars or (nested) arrays
+		dims, _ := arrayShape(rt)
+		nvals := int64(rv.Len()) * nelements(dims)
+		if nvals%repeat != 0 {
+			return fmt.Errorf(
+				"cfitsio: number of values (%d) is not a multiple of the repeat count (%d) of column [%s]",
+				nvals, repeat, n,
+			)
+		}
+		return hdu.writeFlat(icol, beg, nvals/repeat, repeat, rv)
+	}
+}
+
+// colType returns the type code, repeat count and width of column icol (0-based)
+func (hdu *Table) colType(icol int) (TypeCode, int64, int64, error) {
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_coltype(hdu.f.c, C.int(icol+1), &c_type, &c_repeat, &c_width, &c_status)
+	if c_status > 0 {
+		return 0, 0, 0, to_err(c_status)
+	}
+	repeat := int64(c_repeat)
+	if repeat < 1 {
+		repeat = 1
+	}
+	return TypeCode(c_type), repeat, int64(c_width), nil
+}
+
+// rowChunk returns the optimal number of rows to read or write at once
+func (hdu *Table) rowChunk() int64 {
+	c_nrows := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_rowsize(hdu.f.c, &c_nrows, &c_status)
+	if c_status > 0 || c_nrows < 1 {
+		return 1
+	}
+	return int64(c_nrows)
+}
+
+// updateNumRows re-reads the number of rows of the table from the file
+func (hdu *Table) updateNumRows() {
+	c_nrows := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_num_rows(hdu.f.c, &c_nrows, &c_status)
+	if c_status > 0 {
+		return
+	}
+	hdu.nrows = int64(c_nrows)
+}
+
+// readFlat reads nrows rows (of repeat values each) of column icol, starting
+// at row beg, into the slice rv.
+func (hdu *Table) readFlat(icol int, beg, nrows, repeat int64, rv reflect.Value) error {
+	if nrows <= 0 {
+		return nil
+	}
+	dims, elem := arrayShape(rv.Type().Elem())
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return err
+	}
+	stride := repeat / nelements(dims) // number of slice elements per row
+
+	chunk := hdu.rowChunk()
+	c_icol := C.int(icol + 1) // 0-based to 1-based index
+	c_anynul := C.int(0)
+	for irow := int64(0); irow < nrows; irow += chunk {
+		n := chunk
+		if irow+n > nrows {
+			n = nrows - irow
+		}
+		c_status := C.int(0)
+		c_row := C.LONGLONG(beg + irow + 1) // 0-based to 1-based index
+		c_ptr := unsafe.Pointer(rv.Index(int(irow * stride)).UnsafeAddr())
+		C.fits_read_col(hdu.f.c, c_type, c_icol, c_row, 1, C.LONGLONG(n*repeat), nil, c_ptr, &c_anynul, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	return err
+}
+
+// writeFlat writes nrows rows (of repeat values each) from the slice rv into
+// column icol, starting at row beg.
+func (hdu *Table) writeFlat(icol int, beg, nrows, repeat int64, rv reflect.Value) error {
+	if nrows <= 0 {
+		return nil
+	}
+	dims, elem := arrayShape(rv.Type().Elem())
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return err
+	}
+	stride := repeat / nelements(dims) // number of slice elements per row
+
+	chunk := hdu.rowChunk()
+	c_icol := C.int(icol + 1) // 0-based to 1-based index
+	for irow := int64(0); irow < nrows; irow += chunk {
+		n := chunk
+		if irow+n > nrows {
+			n = nrows - irow
+		}
+		c_status := C.int(0)
+		c_row := C.LONGLONG(beg + irow + 1) // 0-based to 1-based index
+		c_ptr := unsafe.Pointer(rv.Index(int(irow * stride)).UnsafeAddr())
+		C.fits_write_col(hdu.f.c, c_type, c_icol, c_row, 1, C.LONGLONG(n*repeat), c_ptr, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	return err
+}
+
+// readVLA reads the variable length array at row irow of column icol into
+// the slice rv.
+func (hdu *Table) readVLA(icol int, irow int64, rv reflect.Value) error {
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_len := C.long(0)
+	c_off := C.long(0)
+	c_status := C.int(0)
+	C.fits_read_descript(hdu.f.c, c_icol, c_irow, &c_len, &c_off, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+
+	n := int(c_len)
+	rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+	if n == 0 {
+		return nil
+	}
+
+	c_type, err := colDataType(rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	c_anynul := C.int(0)
+	c_ptr := unsafe.Pointer(rv.Index(0).UnsafeAddr())
+	C.fits_read_col(hdu.f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(n), nil, c_ptr, &c_anynul, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return err
+}
+
+// stringsPerRow returns the number of strings held by each row of a string
+// column with the given repeat count and width, e.g. 2 for "20A10".
+func stringsPerRow(repeat, width int64) int64 {
+	if width <= 0 || repeat <= width {
+		return 1
+	}
+	return repeat / width
+}
+
+// readStrings reads rv.Len() strings (of at most width characters, nsub per
+// row) of column icol, starting at row beg, into the slice rv.
+func (hdu *Table) readStrings(icol int, beg, width, nsub int64, rv reflect.Value) error {
+	nstrs := int64(rv.Len())
+	if nstrs <= 0 {
+		return nil
+	}
+
+	chunk := hdu.rowChunk() * nsub
+	if chunk > nstrs {
+		chunk = nstrs
+	}
+	c_sz := C.int(chunk)
+	c_strs := C.char_array_new(c_sz)
+	defer C.free(unsafe.Pointer(c_strs))
+	for i := C.int(0); i < c_sz; i++ {
+		c_str := C.CStringN(C.int(width + 1))
+		defer C.free(unsafe.Pointer(c_str))
+		C.char_array_set(c_strs, i, c_str)
+	}
+
+	c_icol := C.int(icol + 1) // 0-based to 1-based index
+	c_anynul := C.int(0)
+	for istr := int64(0); istr < nstrs; istr += chunk {
+		n := chunk
+		if istr+n > nstrs {
+			n = nstrs - istr
+		}
+		c_status := C.int(0)
+		c_row := C.LONGLONG(beg + istr/nsub + 1) // 0-based to 1-based index
+		c_ptr := unsafe.Pointer(c_strs)
+		C.fits_read_col(hdu.f.c, C.TSTRING, c_icol, c_row, 1, C.LONGLONG(n), nil, c_ptr, &c_anynul, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		for i := int64(0); i < n; i++ {
+			str := C.GoString(C.char_array_get(c_strs, C.int(i)))
+			rv.Index(int(istr + i)).SetString(str)
+		}
+	}
+	return nil
+}
+
+// writeStrings writes the strings held by the slice rv (nsub per row) into
+// column icol, starting at row beg.
+func (hdu *Table) writeStrings(icol int, beg, nsub int64, rv reflect.Value) error {
+	nstrs := int64(rv.Len())
+	if nstrs <= 0 {
+		return nil
+	}
+
+	chunk := hdu.rowChunk() * nsub
+	if chunk > nstrs {
+		chunk = nstrs
+	}
+	c_strs := C.char_array_new(C.int(chunk))
+	defer C.free(unsafe.Pointer(c_strs))
+
+	c_icol := C.int(icol + 1) // 0-based to 1-based index
+	for istr := int64(0); istr < nstrs; istr += chunk {
+		n := chunk
+		if istr+n > nstrs {
+			n = nstrs - istr
+		}
+		for i := int64(0); i < n; i++ {
+			c_str := C.CString(rv.Index(int(istr + i)).String())
+			C.char_array_set(c_strs, C.int(i), c_str)
+		}
+		c_status := C.int(0)
+		c_row := C.LONGLONG(beg + istr/nsub + 1) // 0-based to 1-based index
+		c_ptr := unsafe.Pointer(c_strs)
+		C.fits_write_col(hdu.f.c, C.TSTRING, c_icol, c_row, 1, C.LONGLONG(n), c_ptr, &c_status)
+		for i := int64(0); i < n; i++ {
+			C.free(unsafe.Pointer(C.char_array_get(c_strs, C.int(i))))
+		}
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	return nil
+}
+
+// colDataType returns the CFITSIO datatype corresponding to the Go type rt
+func colDataType(rt reflect.Type) (C.int, error) {
+	switch rt.Kind() {
+	case reflect.Bool:
+		return C.TLOGICAL, nil
+	case reflect.Uint8:
+		return C.TBYTE, nil
+	case reflect.Int8:
+		return C.TSBYTE, nil
+	case reflect.Int16:
+		return C.TSHORT, nil
+	case reflect.Uint16:
+		return C.TUSHORT, nil
+	case reflect.Int32:
+		return C.TINT, nil
+	case reflect.Uint32:
+		return C.TUINT, nil
+	case reflect.Int64:
+		return C.TLONGLONG, nil
+	case reflect.Uint64:
+		return C.TULONGLONG, nil
+	case reflect.Uint:
+		return C.TULONG, nil
+	case reflect.Int:
+		return C.TLONG, nil
+	case reflect.Float32:
+		return C.TFLOAT, nil
+	case reflect.Float64:
+		return C.TDOUBLE, nil
+	case reflect.Complex64:
+		return C.TCOMPLEX, nil
+	case reflect.Complex128:
+		return C.TDBLCOMPLEX, nil
+	}
+	return 0, fmt.Errorf("cfitsio: invalid column type [%v]", rt)
+}
+
+// EOF
@@ -0,0 +1,125 @@
+package cfitsio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadWriteColumn(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cols := []Column{
+		{Name: "X", Format: "D"},
+		{Name: "N", Format: "J"},
+		{Name: "S", Format: "10A"},
+		{Name: "V", Format: "3E"},
+		{Name: "A", Format: "QD"},
+		{Name: "W", Format: "8A4"},
+	}
+	table, err := NewTable(f, "test", cols, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer table.Close()
+
+	xs := []float64{0, 1.5, 2.5, 3.5, 4.5}
+	ns := []int32{0, -1, 2, -3, 4}
+	ss := []string{"", "a", "bb", "ccc", "dddd"}
+	vs := [][]float32{{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, {9, 10, 11}, {12, 13, 14}}
+	as := [][]float64{{}, {1}, {1, 2}, {1, 2, 3}, {1, 2, 3, 4}}
+	ws := []string{"a", "b", "cc", "dd", "eee", "fff", "gggg", "hhhh", "i", "j"}
+
+	for _, tc := range []struct {
+		name string
+		data interface{}
+	}{
+		{"X", xs},
+		{"N", &ns},
+		{"S", ss},
+		{"V", vs},
+		{"A", as},
+		{"W", ws},
+	} {
+		err = table.WriteColumn(tc.name, 0, tc.data)
+		if err != nil {
+			t.Fatalf("error writing column [%s]: %v", tc.name, err)
+		}
+	}
+
+	if table.NumRows() != int64(len(xs)) {
+		t.Fatalf("expected %d rows. got %d", len(xs), table.NumRows())
+	}
+
+	var (
+		rxs []float64
+		rns []int32
+		rss []string
+		rvs [][]float32
+		ras [][]float64
+		rfs []float32
+		rws []string
+	)
+	for _, tc := range []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{"X", &rxs, xs},
+		{"N", &rns, ns},
+		{"S", &rss, ss},
+		{"V", &rvs, vs},
+		{"A", &ras, as},
+		{"V", &rfs, []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}},
+		{"W", &rws, ws},
+	} {
+		err = table.ReadColumn(tc.name, 0, table.NumRows(), tc.data)
+		if err != nil {
+			t.Fatalf("error reading column [%s]: %v", tc.name, err)
+		}
+		got := reflect.ValueOf(tc.data).Elem().Interface()
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("column [%s]: expected\nref=%v\ngot=%v", tc.name, tc.want, got)
+		}
+	}
+
+	// sub-range
+	err = table.ReadColumn("N", 1, 3, &rns)
+	if err != nil {
+		t.Fatalf("error reading column [N]: %v", err)
+	}
+	if !reflect.DeepEqual(rns, ns[1:3]) {
+		t.Fatalf("expected\nref=%v\ngot=%v", ns[1:3], rns)
+	}
+
+	// overwrite, then append
+	err = table.WriteColumn("X", 4, []float64{-4, -5})
+	if err != nil {
+		t.Fatalf("error writing column [X]: %v", err)
+	}
+	if table.NumRows() != 6 {
+		t.Fatalf("expected 6 rows. got %d", table.NumRows())
+	}
+	err = table.ReadColumn("X", 3, 10, &rxs)
+	if err != nil {
+		t.Fatalf("error reading column [X]: %v", err)
+	}
+	if want := []float64{3.5, -4, -5}; !reflect.DeepEqual(rxs, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, rxs)
+	}
+
+	err = table.WriteColumn("V", 0, [][]float32{{1, 2}})
+	if err == nil {
+		t.Fatalf("expected an error writing a short vector")
+	}
+	err = table.WriteColumn("W", 0, []string{"a", "b", "c"})
+	if err == nil {
+		t.Fatalf("expected an error writing an incomplete row of strings")
+	}
+	err = table.ReadColumn("NONE", 0, 1, &rxs)
+	if err == nil {
+		t.Fatalf("expected an error reading a missing column")
+	}
+}
+
+// EOF
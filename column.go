@@ -6,43 +6,222 @@ package cfitsio
 import "C"
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 	"unsafe"
 )
 
 // Value is a value in a FITS table
 type Value interface{}
 
-// Column represents a column in a FITS table
+// Column represents a column in a FITS table.
+//
+// Columns with a TSCAL or TZERO keyword hold scaled values: values are read
+// as physical values (raw*Bscale + Bzero) and physical values are converted
+// back to raw values when written, unless raw mode has been enabled with
+// Table.SetRawMode.
+// Unsigned 16, 32 and 64b integers are stored as signed integers with the
+// TZERO offsets 32768, 2147483648 and 9223372036854775808 ("U", "V" and "W"
+// formats.)
 type Column struct {
-	Name    string  // column name, corresponding to ``TTYPE`` keyword
-	Format  string  // column format, corresponding to ``TFORM`` keyword
-	Unit    string  // column unit, corresponding to ``TUNIT`` keyword
-	Null    string  // null value, corresponding to ``TNULL`` keyword
-	Bscale  float64 // bscale value, corresponding to ``TSCAL`` keyword
-	Bzero   float64 // bzero value, corresponding to ``TZERO`` keyword
-	Display string  // display format, corresponding to ``TDISP`` keyword
-	Dim     []int64 // column dimension corresponding to ``TDIM`` keyword
-	Start   int64   // column starting position, corresponding to ``TBCOL`` keyword
-	IsVLA   bool    // whether this is a variable length array
-	Value   Value   // value at current row
+	Name       string  // column name, corresponding to ``TTYPE`` keyword
+	Format     string  // column format, corresponding to ``TFORM`` keyword
+	Unit       string  // column unit, corresponding to ``TUNIT`` keyword
+	Null       Value   // null value, corresponding to ``TNULL`` keyword (int64 for binary tables, string for ASCII tables)
+	Bscale     float64 // bscale value, corresponding to ``TSCAL`` keyword
+	Bzero      float64 // bzero value, corresponding to ``TZERO`` keyword
+	Display    string  // display format, corresponding to ``TDISP`` keyword
+	Dim        []int64 // column dimension corresponding to ``TDIM`` keyword
+	Start      int64   // column starting position, corresponding to ``TBCOL`` keyword
+	IsVLA      bool    // whether this is a variable length array
+	Descriptor byte    // descriptor of variable length arrays: 'P' (32b) or 'Q' (64b, default)
+	MaxLen     int64   // maximum length of variable length arrays, as in the ``TFORM`` "PE(12)" (0 if unknown)
+	Value      Value   // value at current row
+
+	raw  bool     // whether TSCAL/TZERO scaling is disabled
+	tref *timeRef // time reference of the table (TIMESYS, MJDREF, ...)
 }
 
 // inferFormat infers the FITS format associated with a Column, according to its HDUType and Go type.
 func (col *Column) inferFormat(htype HDUType) error {
 	var err error
+	rt := reflect.TypeOf(col.Value)
+	if rt != nil && rt.Kind() == reflect.Array && len(col.Dim) == 0 {
+		if dims, _ := arrayShape(rt); len(dims) > 1 {
+			col.Dim = dims
+		}
+	}
+
 	if col.Format != "" {
 		return nil
 	}
 
+	if bits, ok := col.Value.(BitArray); ok {
+		if htype != BINARY_TBL || bits.Len() <= 0 {
+			return fmt.Errorf("cfitsio: %v can not handle a %d-bits BitArray", htype, bits.Len())
+		}
+		col.Format = strconv.Itoa(bits.Len()) + "X"
+		return err
+	}
+
+	if rt != nil && rt.Kind() == reflect.Slice && rt.Elem().Kind() == reflect.Slice {
+		// nested slices: the shape is given by Dim
+		depth, elem := sliceShape(rt)
+		if depth != len(col.Dim) {
+			return fmt.Errorf("cfitsio: column [%s] needs a %d-dimensional Dim for [%T]", col.Name, depth, col.Value)
+		}
+		str := gotype2FITS(reflect.Zero(elem).Interface(), htype)
+		if str == "" || htype != BINARY_TBL {
+			return fmt.Errorf("cfitsio: %v can not handle [%T]", htype, col.Value)
+		}
+		col.Format = strconv.FormatInt(nelements(col.Dim), 10) + str
+		return err
+	}
+
+	if rt == timeType {
+		// times are written as FITS date strings, with milliseconds
+		if htype == ASCII_TBL {
+			col.Format = "A23"
+		} else {
+			col.Format = "23A"
+		}
+		return err
+	}
+
+	if htype == ASCII_TBL && col.Display != "" && rt != nil {
+		if str := asciiFormat(col.Display, rt); str != "" {
+			col.Format = str
+			return err
+		}
+	}
+
 	str := gotype2FITS(col.Value, htype)
 	if str == "" {
 		return fmt.Errorf("cfitsio: %v can not handle [%T]", htype, col.Value)
 	}
 	col.Format = str
+	if rt.Kind() == reflect.Slice {
+		err = col.vlaFormat()
+	}
 	return err
 }
 
+// writeKeys writes the keywords describing this Column (besides TTYPE, TFORM
+// and TUNIT) into the current HDU of file f, for the column icol (0-based).
+func (col *Column) writeKeys(f *File, icol int, htype HDUType) error {
+	if col.Bscale != 0 && col.Bscale != 1 {
+		err := writeCard(f, &Card{Name: fmt.Sprintf("TSCAL%d", icol+1), Value: col.Bscale})
+		if err != nil {
+			return err
+		}
+	}
+
+	if col.Bzero != 0 {
+		var zero Value = col.Bzero
+		if col.Bzero == math.Trunc(col.Bzero) && math.Abs(col.Bzero) <= 1<<63 {
+			// write integral offsets exactly: CFITSIO recognizes the unsigned
+			// integers conventions by comparing TZERO with 2^15, 2^31 and 2^63.
+			if col.Bzero >= 1<<63 {
+				zero = uint64(col.Bzero)
+			} else {
+				zero = int64(col.Bzero)
+			}
+		}
+		err := writeCard(f, &Card{Name: fmt.Sprintf("TZERO%d", icol+1), Value: zero})
+		if err != nil {
+			return err
+		}
+	}
+
+	if col.Null != nil {
+		err := writeCard(f, &Card{Name: fmt.Sprintf("TNULL%d", icol+1), Value: col.Null})
+		if err != nil {
+			return err
+		}
+	}
+
+	if col.Display != "" {
+		err := writeCard(f, &Card{Name: fmt.Sprintf("TDISP%d", icol+1), Value: col.Display})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(col.Dim) > 0 && htype == BINARY_TBL {
+		c_naxes := make([]C.long, len(col.Dim))
+		for i, dim := range col.Dim {
+			c_naxes[i] = C.long(dim)
+		}
+		c_status := C.int(0)
+		C.fits_write_tdim(f.c, C.int(icol+1), C.int(len(c_naxes)), &c_naxes[0], &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	return nil
+}
+
+// checkNull returns an error if this Column has a Null value which can not be
+// stored as TNULL: in binary tables, only integer columns (B, I, J or K,
+// possibly in a variable length array) have one, NaN being the null value of
+// floating-point columns.
+func (col *Column) checkNull(htype HDUType) error {
+	if col.Null == nil || htype != BINARY_TBL {
+		return nil
+	}
+	_, code, err := parseTForm(col.Format)
+	if err != nil {
+		return err
+	}
+	if code[0] == 'P' || code[0] == 'Q' {
+		code = code[1:]
+	}
+	if code == "" || !strings.ContainsRune("BIJK", rune(code[0])) {
+		return fmt.Errorf("cfitsio: column [%s] of format %q can not have a null value", col.Name, col.Format)
+	}
+	return nil
+}
+
+// resetValue sets the Value of this Column to the zero value of the Go type
+// corresponding to the column icol (0-based) of the current HDU of file f.
+// Scaled columns use their physical type, unless in raw mode.
+func (col *Column) resetValue(f *File, icol int) error {
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	c_col := C.int(icol + 1) // 1-based index
+	if col.raw {
+		C.fits_get_coltype(f.c, c_col, &c_type, &c_repeat, &c_width, &c_status)
+	} else {
+		C.fits_get_eqcoltype(f.c, c_col, &c_type, &c_repeat, &c_width, &c_status)
+	}
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	col.Value = govalue_from_typecode(TypeCode(c_type))
+	switch TypeCode(c_type) {
+	case TBIT:
+		col.Value = NewBitArray(int(c_repeat))
+	case TSTRING:
+		// no-op
+	default:
+		if c_type > 0 && c_repeat > 1 {
+			// vector column: use a (nested) array, shaped after TDIM
+			dims := col.Dim
+			if nelements(dims) != int64(c_repeat) {
+				dims = []int64{int64(c_repeat)}
+			}
+			rt := arrayType(reflect.TypeOf(col.Value), dims)
+			col.Value = reflect.Zero(rt).Interface()
+		}
+	}
+	return nil
+}
+
 // read reads the value at column number icol and row irow, into ptr.
 // icol and irow are 0-based indices.
 func (col *Column) read(f *File, icol int, irow int64, ptr interface{}) error {
@@ -56,10 +235,42 @@ func (col *Column) read(f *File, icol int, irow int64, ptr interface{}) error {
 
 	var value interface{}
 	rv := reflect.ValueOf(ptr).Elem()
+	if ok, err := col.readNull(f, icol, irow, rv); ok {
+		return err
+	}
 	rt := reflect.TypeOf(rv.Interface())
 
+	if rt == timeType {
+		t, err := col.readTime(f, icol, irow)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(t))
+		col.Value = t
+		return nil
+	}
+
+	if rt == reflect.TypeOf(BitArray{}) {
+		bits, err := col.readBits(f, icol, irow)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(bits))
+		col.Value = bits
+		return nil
+	}
+
 	switch rt.Kind() {
 	case reflect.Bool:
+		if col.asciiLogical() {
+			c_type = C.TSTRING
+			c_value := C.CStringN(2)
+			defer C.free(unsafe.Pointer(c_value))
+			c_ptr := unsafe.Pointer(&c_value)
+			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, c_ptr, &c_anynul, &c_status)
+			value = C.GoString(c_value) == "T"
+			break
+		}
 		c_type = C.TLOGICAL
 		c_value := C.char(0) // 'F'
 		c_ptr := unsafe.Pointer(&c_value)
@@ -88,15 +299,15 @@ func (col *Column) read(f *File, icol int, irow int64, ptr interface{}) error {
 		value = uint32(c_value)
 
 	case reflect.Uint64:
-		c_type = C.TULONG
-		var c_value C.ulong
+		c_type = C.TULONGLONG
+		var c_value C.ULONGLONG
 		c_ptr := unsafe.Pointer(&c_value)
 		C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, c_ptr, &c_anynul, &c_status)
 		value = uint64(c_value)
 
 	case reflect.Uint:
-		c_type = C.TULONG
-		var c_value C.ulong
+		c_type = C.TULONGLONG
+		var c_value C.ULONGLONG
 		c_ptr := unsafe.Pointer(&c_value)
 		C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, c_ptr, &c_anynul, &c_status)
 		value = uint(c_value)
@@ -180,133 +391,19 @@ func (col *Column) read(f *File, icol int, irow int64, ptr interface{}) error {
 		value = C.GoString(c_value)
 
 	case reflect.Array:
-		c_len := C.LONGLONG(rt.Len())
-		switch rt.Elem().Kind() {
-		case reflect.Bool:
-			c_type = C.TLOGICAL
-			v := make([]bool, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Uint8:
-			c_type = C.TBYTE
-			v := make([]uint8, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Uint16:
-			c_type = C.TUSHORT
-			v := make([]uint16, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Uint32:
-			c_type = C.TUINT
-			v := make([]uint32, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Uint64:
-			c_type = C.TULONG
-			v := make([]uint64, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Uint:
-			c_type = C.TULONG
-			v := make([]uint, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Int8:
-			c_type = C.TSBYTE
-			v := make([]int8, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Int16:
-			c_type = C.TSHORT
-			v := make([]int16, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Int32:
-			c_type = C.TINT
-			v := make([]int32, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Int64:
-			c_type = C.TLONG
-			v := make([]int64, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Int:
-			c_type = C.TLONG
-			v := make([]int, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Float32:
-			c_type = C.TFLOAT
-			v := make([]float32, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Float64:
-			c_type = C.TDOUBLE
-			v := make([]float64, int(c_len), int(c_len))
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Complex64:
-			c_type = C.TCOMPLEX
-			v := make([]complex64, int(c_len), int(c_len)) // FIXME: assume same binary layout
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-
-		case reflect.Complex128:
-			c_type = C.TDBLCOMPLEX
-			v := make([]complex128, int(c_len), int(c_len)) // FIXME: assume same binary layout
-			value = v
-			slice := (*reflect.SliceHeader)((unsafe.Pointer(&v)))
-			c_ptr := unsafe.Pointer(slice.Data)
-			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
-		default:
-			panic(fmt.Errorf("invalid type [%T]", value))
+		value, err = col.readArray(f, icol, irow, rt)
+		if err != nil {
+			return err
 		}
 
 	case reflect.Slice:
 		switch rt.Elem().Kind() {
+		case reflect.Slice:
+			value, err = col.readSlices(f, icol, irow, rt)
+			if err != nil {
+				return err
+			}
+
 		case reflect.Bool:
 			c_type = C.TLOGICAL
 			c_len := C.long(0)
@@ -368,7 +465,7 @@ func (col *Column) read(f *File, icol int, irow int64, ptr interface{}) error {
 			C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(c_len), nil, c_ptr, &c_anynul, &c_status)
 
 		case reflect.Uint64:
-			c_type = C.TULONG
+			c_type = C.TULONGLONG
 			c_len := C.long(0)
 			c_off := C.long(0)
 			C.fits_read_descript(f.c, c_icol, c_irow, &c_len, &c_off, &c_status)
@@ -558,11 +655,35 @@ func (col *Column) write(f *File, icol int, irow int64, value interface{}) error
 	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
 	c_status := C.int(0)
 
+	if ok, err := col.writeNull(f, icol, irow, value); ok {
+		return err
+	}
+
+	if bits, ok := value.(BitArray); ok {
+		return col.writeBits(f, icol, irow, bits)
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return col.writeTime(f, icol, irow, t)
+	}
+
 	rv := reflect.ValueOf(value)
 	rt := reflect.TypeOf(value)
 
 	switch rt.Kind() {
 	case reflect.Bool:
+		if col.asciiLogical() {
+			c_type = C.TSTRING
+			c_value := C.CString("F")
+			if value.(bool) {
+				C.free(unsafe.Pointer(c_value))
+				c_value = C.CString("T")
+			}
+			defer C.free(unsafe.Pointer(c_value))
+			c_ptr := unsafe.Pointer(&c_value)
+			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, &c_status)
+			break
+		}
 		c_type = C.TLOGICAL
 		c_value := C.char(0) // 'F'
 		if value.(bool) {
@@ -590,14 +711,14 @@ func (col *Column) write(f *File, icol int, irow int64, value interface{}) error
 		C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, &c_status)
 
 	case reflect.Uint64:
-		c_type = C.TULONG
-		c_value := C.ulong(value.(uint64))
+		c_type = C.TULONGLONG
+		c_value := C.ULONGLONG(value.(uint64))
 		c_ptr := unsafe.Pointer(&c_value)
 		C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, &c_status)
 
 	case reflect.Uint:
-		c_type = C.TULONG
-		c_value := C.ulong(value.(uint))
+		c_type = C.TULONGLONG
+		c_value := C.ULONGLONG(value.(uint))
 		c_ptr := unsafe.Pointer(&c_value)
 		C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, 1, c_ptr, &c_status)
 
@@ -664,6 +785,12 @@ func (col *Column) write(f *File, icol int, irow int64, value interface{}) error
 
 	case reflect.Slice:
 		switch rt.Elem().Kind() {
+		case reflect.Slice:
+			err = col.writeSlices(f, icol, irow, rv)
+			if err != nil {
+				return err
+			}
+
 		case reflect.Bool:
 			c_type = C.TLOGICAL
 			value := value.([]bool)
@@ -693,7 +820,7 @@ func (col *Column) write(f *File, icol int, irow int64, value interface{}) error
 			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(slice.Len), c_ptr, &c_status)
 
 		case reflect.Uint64:
-			c_type = C.TULONG
+			c_type = C.TULONGLONG
 			value := value.([]uint64)
 			slice := (*reflect.SliceHeader)((unsafe.Pointer(&value)))
 			c_ptr := unsafe.Pointer(slice.Data)
@@ -773,83 +900,214 @@ func (col *Column) write(f *File, icol int, irow int64, value interface{}) error
 		}
 
 	case reflect.Array:
-		//rp := reflect.PtrTo(rv.Type())
-		c_ptr := unsafe.Pointer(rv.Pointer())
-		c_len := C.LONGLONG(rt.Len())
+		err = col.writeArray(f, icol, irow, rv)
+		if err != nil {
+			return err
+		}
 
-		switch rt.Elem().Kind() {
-		case reflect.Bool:
-			c_type = C.TLOGICAL
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	default:
+		panic(fmt.Errorf("unhandled type '%T' (%v)", value, rt.Kind()))
+	}
 
-		case reflect.Uint8:
-			c_type = C.TBYTE
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	if c_status > 0 {
+		err = to_err(c_status)
+	}
 
-		case reflect.Uint16:
-			c_type = C.TUSHORT
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	return err
+}
 
-		case reflect.Uint32:
-			c_type = C.TUINT
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+// readArray reads the cell at column icol and row irow into a new (possibly
+// nested) Go array of type rt.
+func (col *Column) readArray(f *File, icol int, irow int64, rt reflect.Type) (interface{}, error) {
+	dims, elem := arrayShape(rt)
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return nil, err
+	}
 
-		case reflect.Uint64:
-			c_type = C.TULONG
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	v := reflect.New(rt)
+	c_ptr := unsafe.Pointer(v.Pointer())
+	c_len := C.LONGLONG(nelements(dims))
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_anynul := C.int(0)
+	c_status := C.int(0)
+	C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, c_len, nil, c_ptr, &c_anynul, &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+	return v.Elem().Interface(), err
+}
 
-		case reflect.Uint:
-			c_type = C.TULONG
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+// readSlices reads the cell at column icol and row irow into new nested
+// slices of type rt, shaped after the TDIM of the column.
+func (col *Column) readSlices(f *File, icol int, irow int64, rt reflect.Type) (interface{}, error) {
+	depth, elem := sliceShape(rt)
+	if depth != len(col.Dim) {
+		return nil, fmt.Errorf(
+			"cfitsio: column [%s] has %d dimensions (TDIM=%v). got %v",
+			col.Name, len(col.Dim), col.Dim, rt,
+		)
+	}
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return nil, err
+	}
 
-		case reflect.Int8:
-			c_type = C.TSBYTE
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	n := int(nelements(col.Dim))
+	flat := reflect.MakeSlice(reflect.SliceOf(elem), n, n)
+	if n > 0 {
+		c_ptr := unsafe.Pointer(flat.Pointer())
+		c_icol := C.int(icol + 1)      // 0-based to 1-based index
+		c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+		c_anynul := C.int(0)
+		c_status := C.int(0)
+		C.fits_read_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(n), nil, c_ptr, &c_anynul, &c_status)
+		if c_status > 0 {
+			return nil, to_err(c_status)
+		}
+	}
+	return reshape(flat, rt, col.Dim).Interface(), err
+}
 
-		case reflect.Int16:
-			c_type = C.TSHORT
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+// writeArray writes the (possibly nested) Go array rv at column icol and row irow.
+func (col *Column) writeArray(f *File, icol int, irow int64, rv reflect.Value) error {
+	dims, elem := arrayShape(rv.Type())
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return err
+	}
 
-		case reflect.Int32:
-			c_type = C.TINT
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	// take an addressable copy of the array
+	v := reflect.New(rv.Type())
+	v.Elem().Set(rv)
 
-		case reflect.Int64:
-			c_type = C.TLONG
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	c_ptr := unsafe.Pointer(v.Pointer())
+	c_len := C.LONGLONG(nelements(dims))
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_status := C.int(0)
+	C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return err
+}
 
-		case reflect.Int:
-			c_type = C.TLONG
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+// writeSlices writes the nested slices rv at column icol and row irow.
+// The shape of rv must match the TDIM of the column.
+func (col *Column) writeSlices(f *File, icol int, irow int64, rv reflect.Value) error {
+	depth, elem := sliceShape(rv.Type())
+	if depth != len(col.Dim) {
+		return fmt.Errorf(
+			"cfitsio: column [%s] has %d dimensions (TDIM=%v). got %v",
+			col.Name, len(col.Dim), col.Dim, rv.Type(),
+		)
+	}
+	c_type, err := colDataType(elem)
+	if err != nil {
+		return err
+	}
 
-		case reflect.Float32:
-			c_type = C.TFLOAT
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	n := int(nelements(col.Dim))
+	flat := reflect.MakeSlice(reflect.SliceOf(elem), 0, n)
+	flat, err = flatten(flat, rv, col.Dim)
+	if err != nil {
+		return fmt.Errorf("cfitsio: column [%s]: %v", col.Name, err)
+	}
+	if n == 0 {
+		return nil
+	}
 
-		case reflect.Float64:
-			c_type = C.TDOUBLE
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+	c_ptr := unsafe.Pointer(flat.Pointer())
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_status := C.int(0)
+	C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, C.LONGLONG(n), c_ptr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return err
+}
 
-		case reflect.Complex64:
-			c_type = C.TCOMPLEX
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
+// arrayType returns the (nested) Go array type holding a cell of dimensions
+// dims (in TDIM order: the first dimension varies fastest) of elem values.
+// ie: arrayType(float32, [4,3]) is [3][4]float32
+func arrayType(elem reflect.Type, dims []int64) reflect.Type {
+	rt := elem
+	for _, dim := range dims {
+		rt = reflect.ArrayOf(int(dim), rt)
+	}
+	return rt
+}
 
-		case reflect.Complex128:
-			c_type = C.TDBLCOMPLEX
-			C.fits_write_col(f.c, c_type, c_icol, c_irow, 1, c_len, c_ptr, &c_status)
-		default:
-			panic(fmt.Errorf("unhandled type '%T'", value))
-		}
+// arrayShape returns the dimensions (in TDIM order) and the element type of
+// the (possibly nested) Go array type rt.
+// It is the inverse of arrayType.
+func arrayShape(rt reflect.Type) ([]int64, reflect.Type) {
+	var dims []int64
+	for rt.Kind() == reflect.Array {
+		dims = append([]int64{int64(rt.Len())}, dims...)
+		rt = rt.Elem()
+	}
+	return dims, rt
+}
 
-	default:
-		panic(fmt.Errorf("unhandled type '%T' (%v)", value, rt.Kind()))
+// sliceShape returns the nesting depth and the element type of the nested slices type rt.
+func sliceShape(rt reflect.Type) (int, reflect.Type) {
+	depth := 0
+	for rt.Kind() == reflect.Slice {
+		depth++
+		rt = rt.Elem()
 	}
+	return depth, rt
+}
 
-	if c_status > 0 {
-		err = to_err(c_status)
+// nelements returns the number of elements of a cell of dimensions dims
+func nelements(dims []int64) int64 {
+	n := int64(1)
+	for _, dim := range dims {
+		n *= dim
 	}
+	return n
+}
 
-	return err
+// reshape returns the flat slice as nested slices of type rt and dimensions
+// dims (in TDIM order). The nested slices share the memory of flat.
+func reshape(flat reflect.Value, rt reflect.Type, dims []int64) reflect.Value {
+	last := len(dims) - 1
+	if last == 0 {
+		return flat
+	}
+	n := int(dims[last])
+	stride := int(nelements(dims[:last]))
+	out := reflect.MakeSlice(rt, n, n)
+	for i := 0; i < n; i++ {
+		sub := flat.Slice3(i*stride, (i+1)*stride, (i+1)*stride)
+		out.Index(i).Set(reshape(sub, rt.Elem(), dims[:last]))
+	}
+	return out
+}
+
+// flatten appends the values of the nested slices rv of dimensions dims (in
+// TDIM order) to flat.
+// It is the inverse of reshape.
+func flatten(flat, rv reflect.Value, dims []int64) (reflect.Value, error) {
+	var err error
+	last := len(dims) - 1
+	if int64(rv.Len()) != dims[last] {
+		return flat, fmt.Errorf("invalid shape (got %d values. expected %d)", rv.Len(), dims[last])
+	}
+	if last == 0 {
+		return reflect.AppendSlice(flat, rv), err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		flat, err = flatten(flat, rv.Index(i), dims[:last])
+		if err != nil {
+			return flat, err
+		}
+	}
+	return flat, err
 }
 
 // EOF
@@ -0,0 +1,351 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// CopyTableRange appends the rows interval [beg,end) of src to dst.
+// dst must have the same columns (same formats) than src.
+//
+// Rows are copied as raw bytes, by batches of rows, if both tables have the
+// same layout (see sameLayout.)
+// Otherwise, and for variable length arrays (as their descriptors point into
+// the heap of src), rows are copied value by value.
+func CopyTableRange(dst, src *Table, beg, end int64) error {
+	err := checkSchema("CopyTableRange", dst, src)
+	if err != nil {
+		return err
+	}
+	if beg < 0 || end > src.nrows || beg > end {
+		return fmt.Errorf("cfitsio: invalid rows range [%d, %d) (nrows=%d)", beg, end, src.nrows)
+	}
+	defer dst.updateNumRows()
+	return copyRange(dst, src, beg, end, dst.nrows)
+}
+
+// CopyTableWhere appends the rows of src for which the boolean CFITSIO row
+// filter expression expr is true, to dst.
+// dst must have the same columns (same formats) than src.
+func CopyTableWhere(dst, src *Table, expr string) error {
+	if src == nil {
+		return fmt.Errorf("cfitsio: src pointer is nil")
+	}
+	return src.Select(dst, expr)
+}
+
+// CopyTableColumns copies the columns of src named after the keys of cols
+// into the columns of dst named after the corresponding values, e.g.
+// {"X": "X", "ENERGY": "E"}.
+// Destination columns are created (after the last one) if they do not exist.
+// All the rows of src are copied, starting at the first row of dst, and
+// values are converted to the format of the destination columns.
+func CopyTableColumns(dst, src *Table, cols map[string]string) error {
+	if dst == nil {
+		return fmt.Errorf("cfitsio: dst pointer is nil")
+	}
+	if src == nil {
+		return fmt.Errorf("cfitsio: src pointer is nil")
+	}
+
+	names := make([]string, 0, len(cols))
+	for n := range cols {
+		if src.Index(n) < 0 {
+			return fmt.Errorf("cfitsio: no column named [%s] in src", n)
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		err := copyColumn(dst, src, src.Index(n), cols[n])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyColumn copies the column icol (0-based) of src into the column named
+// name of dst, creating it if needed.
+func copyColumn(dst, src *Table, icol int, name string) error {
+	ocol := dst.Index(name)
+	if ocol < 0 {
+		col := src.cols[icol]
+		col.Name = name
+		err := dst.AddColumn(col, -1)
+		if err != nil {
+			return err
+		}
+		ocol = dst.Index(name)
+	}
+
+	if dst.f != src.f {
+		err := dst.seekHDU()
+		if err != nil {
+			return err
+		}
+		err = src.seekHDU()
+		if err != nil {
+			return err
+		}
+		c_status := C.int(0)
+		C.fits_copy_col(src.f.c, dst.f.c, C.int(icol+1), C.int(ocol+1), 0, &c_status)
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+		dst.updateNumRows()
+		return nil
+	}
+
+	// both tables live in the same file: CFITSIO needs both HDUs to be
+	// current at the same time, copy value by value.
+	return copyCells(dst, src, ocol, icol, 0, src.nrows, 0)
+}
+
+// copyCells copies the cells of rows [beg, end) of column icol (0-based) of
+// src into column ocol of dst, starting at row orow, one by one.
+// Undefined (TNULL) scalar cells of src are written as undefined cells.
+func copyCells(dst, src *Table, ocol, icol int, beg, end, orow int64) error {
+	defer dst.updateNumRows()
+	scol := &src.cols[icol]
+	dcol := &dst.cols[ocol]
+	repeat, _, err := parseTForm(scol.Format)
+	if err != nil {
+		return err
+	}
+	nullable := scol.Null != nil && repeat == 1 && !scol.IsVLA
+	for irow := beg; irow < end; irow++ {
+		err = src.seekHDU()
+		if err != nil {
+			return err
+		}
+		null := false
+		if nullable {
+			null, err = scol.isNull(src.f, icol, irow)
+			if err != nil {
+				return err
+			}
+		}
+		if !null {
+			err = scol.read(src.f, icol, irow, &scol.Value)
+			if err != nil {
+				return err
+			}
+		}
+		err = dst.seekHDU()
+		if err != nil {
+			return err
+		}
+		if null {
+			err = dcol.writeUndefined(dst.f, ocol, orow+irow-beg)
+		} else {
+			err = dcol.write(dst.f, ocol, orow+irow-beg, scol.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSchema checks that dst and src have the same columns, for the
+// copying function fct.
+func checkSchema(fct string, dst, src *Table) error {
+	if dst == nil {
+		return fmt.Errorf("cfitsio: dst pointer is nil")
+	}
+	if src == nil {
+		return fmt.Errorf("cfitsio: src pointer is nil")
+	}
+	if len(dst.cols) != len(src.cols) {
+		return fmt.Errorf(
+			"cfitsio: %s needs tables with the same number of columns (dst=%d, src=%d)",
+			fct, len(dst.cols), len(src.cols),
+		)
+	}
+	for i := range src.cols {
+		if !sameFormat(dst.cols[i].Format, src.cols[i].Format) {
+			return fmt.Errorf(
+				"cfitsio: %s needs tables with the same columns (column #%d: dst=%q, src=%q)",
+				fct, i, dst.cols[i].Format, src.cols[i].Format,
+			)
+		}
+	}
+	return nil
+}
+
+// sameLayout returns whether the rows of dst and src, which have the same
+// columns (see checkSchema), can be copied as raw bytes: both tables must have
+// the same type and row width (NAXIS1), and their columns the same TBCOL,
+// TSCAL, TZERO and TNULL values.
+func sameLayout(dst, src *Table) bool {
+	if dst.Type() != src.Type() || dst.rowWidth() != src.rowWidth() {
+		return false
+	}
+	scale := func(v float64) float64 {
+		if v == 0 {
+			return 1
+		}
+		return v
+	}
+	for i := range src.cols {
+		d := &dst.cols[i]
+		s := &src.cols[i]
+		switch {
+		case d.Start != s.Start,
+			scale(d.Bscale) != scale(s.Bscale),
+			d.Bzero != s.Bzero,
+			!reflect.DeepEqual(d.Null, s.Null):
+			return false
+		}
+	}
+	return true
+}
+
+// sameFormat returns whether the TFORM values a and b describe the same
+// data layout, e.g. "D" and "1D", or "PE(12)" and "1PE(42)".
+func sameFormat(a, b string) bool {
+	ra, ca, err := parseTForm(a)
+	if err != nil {
+		return false
+	}
+	rb, cb, err := parseTForm(b)
+	if err != nil {
+		return false
+	}
+	if ra != rb {
+		return false
+	}
+	if i := strings.Index(ca, "("); i > 0 && (ca[0] == 'P' || ca[0] == 'Q') {
+		ca = ca[:i]
+	}
+	if i := strings.Index(cb, "("); i > 0 && (cb[0] == 'P' || cb[0] == 'Q') {
+		cb = cb[:i]
+	}
+	return ca == cb
+}
+
+// copyRows copies the rows of src listed in rows at the end of dst.
+// dst and src must have the same columns.
+func copyRows(dst, src *Table, rows []int64) error {
+	defer dst.updateNumRows()
+	orow := dst.nrows
+	for i := 0; i < len(rows); {
+		// copy runs of consecutive rows in one go
+		j := i + 1
+		for j < len(rows) && rows[j] == rows[j-1]+1 {
+			j++
+		}
+		err := copyRange(dst, src, rows[i], rows[j-1]+1, orow)
+		if err != nil {
+			return err
+		}
+		orow += int64(j - i)
+		i = j
+	}
+	return nil
+}
+
+// copyRange copies the rows [beg, end) of src at row orow of dst.
+// dst and src must have the same columns.
+func copyRange(dst, src *Table, beg, end, orow int64) error {
+	if src.hasVLA() || !sameLayout(dst, src) {
+		// variable length arrays live in the heap of each table, and
+		// different layouts or scalings give different bytes:
+		// copy the values, not the bytes.
+		for icol := range src.cols {
+			err := copyCells(dst, src, icol, icol, beg, end, orow)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if beg >= end {
+		return nil
+	}
+	err := src.seekHDU()
+	if err != nil {
+		return err
+	}
+	nbatch := src.rowChunk()
+	if nbatch > end-beg {
+		nbatch = end - beg
+	}
+
+	width := int64(src.rowWidth())
+	buf := make([]byte, nbatch*width)
+	for irow := beg; irow < end; irow += nbatch {
+		n := nbatch
+		if irow+n > end {
+			n = end - irow
+		}
+		err = src.seekHDU()
+		if err != nil {
+			return err
+		}
+		err = src.readRowBytes(irow, buf[:n*width])
+		if err != nil {
+			return err
+		}
+		err = dst.seekHDU()
+		if err != nil {
+			return err
+		}
+		err = dst.writeRowBytes(orow, buf[:n*width])
+		if err != nil {
+			return err
+		}
+		orow += n
+	}
+	return nil
+}
+
+// rowWidth returns the width in bytes of a row of the table (NAXIS1).
+func (hdu *Table) rowWidth() int {
+	return int(hdu.header.Axes()[0])
+}
+
+// hasVLA returns whether the table has variable length array columns.
+func (hdu *Table) hasVLA() bool {
+	for _, col := range hdu.cols {
+		if col.IsVLA {
+			return true
+		}
+	}
+	return false
+}
+
+// readRowBytes reads the raw bytes of the current HDU starting at row irow
+// (0-based) into buf, which may span several rows.
+func (hdu *Table) readRowBytes(irow int64, buf []byte) error {
+	c_status := C.int(0)
+	c_ptr := (*C.uchar)(unsafe.Pointer(&buf[0]))
+	C.fits_read_tblbytes(hdu.f.c, C.LONGLONG(irow+1), 1, C.LONGLONG(len(buf)), c_ptr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// writeRowBytes writes buf as the raw bytes of the current HDU starting at
+// row irow (0-based). buf may span several rows.
+func (hdu *Table) writeRowBytes(irow int64, buf []byte) error {
+	c_status := C.int(0)
+	c_ptr := (*C.uchar)(unsafe.Pointer(&buf[0]))
+	C.fits_write_tblbytes(hdu.f.c, C.LONGLONG(irow+1), 1, C.LONGLONG(len(buf)), c_ptr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// EOF
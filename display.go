@@ -0,0 +1,312 @@
+package cfitsio
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// displayFormat is a parsed TDISP (or ASCII table TFORM) value: a Fortran-90
+// edit descriptor such as "I6", "F8.3", "E12.4E3" or "A10".
+type displayFormat struct {
+	code string // A, L, I, B, O, Z, F, E, EN, ES, G or D
+	w    int    // field width
+	d    int    // number of decimals (or minimum number of digits for integers)
+	e    int    // number of exponent digits (0: default)
+	hasd bool   // whether d was given
+}
+
+// parseDisplay parses a TDISP value, e.g. "F8.3".
+func parseDisplay(disp string) (displayFormat, error) {
+	var df displayFormat
+	str := strings.ToUpper(strings.TrimSpace(disp))
+	i := 0
+	for i < len(str) && str[i] >= 'A' && str[i] <= 'Z' {
+		i++
+	}
+	df.code = str[:i]
+	switch df.code {
+	case "A", "L", "I", "B", "O", "Z", "F", "E", "EN", "ES", "G", "D":
+	default:
+		return df, fmt.Errorf("cfitsio: invalid display format %q", disp)
+	}
+
+	// w[.d][Ee]
+	rest := str[i:]
+	if j := strings.Index(rest, "E"); j >= 0 {
+		v, err := strconv.Atoi(rest[j+1:])
+		if err != nil {
+			return df, fmt.Errorf("cfitsio: invalid display format %q", disp)
+		}
+		df.e = v
+		rest = rest[:j]
+	}
+	if j := strings.Index(rest, "."); j >= 0 {
+		v, err := strconv.Atoi(rest[j+1:])
+		if err != nil {
+			return df, fmt.Errorf("cfitsio: invalid display format %q", disp)
+		}
+		df.d = v
+		df.hasd = true
+		rest = rest[:j]
+	}
+	if rest != "" {
+		v, err := strconv.Atoi(rest)
+		if err != nil {
+			return df, fmt.Errorf("cfitsio: invalid display format %q", disp)
+		}
+		df.w = v
+	}
+	return df, nil
+}
+
+// FormatValue formats v as FITS display tools do, according to the display
+// format of this Column (TDISP keyword, a Fortran-90 edit descriptor such as
+// "I6", "F8.3", "E12.4", "ES10.3", "G12.4" or "A10".)
+// Values are right-justified in the field width, and values too large for
+// the field are rendered as asterisks.
+// Columns of ASCII tables without TDISP are formatted after their TFORM, and
+// other columns with the default Go formatting.
+func (col *Column) FormatValue(v interface{}) (string, error) {
+	disp := col.Display
+	if disp == "" {
+		if _, err := parseASCIIFormat(col.Format); err == nil {
+			disp = col.Format
+		}
+	}
+	if disp == "" {
+		return fmt.Sprint(v), nil
+	}
+	df, err := parseDisplay(disp)
+	if err != nil {
+		return "", err
+	}
+	return df.format(v)
+}
+
+// format formats v according to the edit descriptor df.
+func (df displayFormat) format(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return df.pad(""), nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return df.pad(""), nil
+	}
+	if isNullType(rv.Type()) {
+		if !rv.Field(1).Bool() {
+			return df.pad(""), nil
+		}
+		rv = rv.Field(0)
+	}
+
+	invalid := func() (string, error) {
+		return "", fmt.Errorf("cfitsio: can not format %T with display format %q", v, df.code)
+	}
+
+	switch df.code {
+	case "A":
+		if rv.Kind() != reflect.String {
+			return invalid()
+		}
+		str := rv.String()
+		if df.w > 0 && len(str) > df.w {
+			str = str[:df.w]
+		}
+		return df.pad(str), nil
+
+	case "L":
+		if rv.Kind() != reflect.Bool {
+			return invalid()
+		}
+		if rv.Bool() {
+			return df.pad("T"), nil
+		}
+		return df.pad("F"), nil
+
+	case "I", "B", "O", "Z":
+		var (
+			neg bool
+			u   uint64
+		)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i := rv.Int()
+			neg = i < 0
+			if neg && df.code == "I" {
+				u = uint64(-i)
+			} else {
+				u = uint64(i)
+				neg = false
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			u = rv.Uint()
+		default:
+			return invalid()
+		}
+		base := map[string]int{"I": 10, "B": 2, "O": 8, "Z": 16}[df.code]
+		digits := strings.ToUpper(strconv.FormatUint(u, base))
+		if df.hasd && len(digits) < df.d {
+			digits = strings.Repeat("0", df.d-len(digits)) + digits
+		}
+		if df.hasd && df.d == 0 && u == 0 {
+			digits = ""
+		}
+		if neg {
+			digits = "-" + digits
+		}
+		return df.pad(digits), nil
+	}
+
+	var x float64
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		x = rv.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x = float64(rv.Uint())
+	default:
+		return invalid()
+	}
+
+	switch {
+	case math.IsNaN(x):
+		return df.pad("NaN"), nil
+	case math.IsInf(x, +1):
+		return df.pad("Inf"), nil
+	case math.IsInf(x, -1):
+		return df.pad("-Inf"), nil
+	}
+
+	switch df.code {
+	case "F":
+		return df.pad(strconv.FormatFloat(x, 'f', df.d, 64)), nil
+	case "E", "D":
+		return df.pad(df.formatExp(x, df.code)), nil
+	case "ES":
+		return df.pad(df.formatSci(x)), nil
+	case "EN":
+		return df.pad(df.formatEng(x)), nil
+	case "G":
+		return df.pad(df.formatGen(x)), nil
+	}
+	return invalid()
+}
+
+// pad right-justifies str in the field width, or fills the field with
+// asterisks if str does not fit.
+func (df displayFormat) pad(str string) string {
+	switch {
+	case df.w <= 0:
+		return str
+	case len(str) > df.w:
+		return strings.Repeat("*", df.w)
+	}
+	return strings.Repeat(" ", df.w-len(str)) + str
+}
+
+// exponent formats the exponent exp after the letter c, with the number of
+// digits of the edit descriptor (at least 2.)
+func (df displayFormat) exponent(c string, exp int) string {
+	sign := "+"
+	if exp < 0 {
+		sign = "-"
+		exp = -exp
+	}
+	ndigits := df.e
+	if ndigits <= 0 {
+		ndigits = 2
+	}
+	digits := strconv.Itoa(exp)
+	if len(digits) < ndigits {
+		digits = strings.Repeat("0", ndigits-len(digits)) + digits
+	}
+	return c + sign + digits
+}
+
+// mantissa returns the d significant digits of |x| and its decimal exponent,
+// such that |x| = 0.digits * 10^exp.
+func mantissa(x float64, d int) (string, int) {
+	if d < 1 {
+		d = 1
+	}
+	if x == 0 {
+		return strings.Repeat("0", d), 0
+	}
+	str := strconv.FormatFloat(math.Abs(x), 'e', d-1, 64) // e.g. 1.234e+01
+	i := strings.Index(str, "e")
+	exp, _ := strconv.Atoi(str[i+1:])
+	digits := strings.Replace(str[:i], ".", "", 1)
+	return digits, exp + 1
+}
+
+// formatExp formats x in the Fortran E (or D) form, e.g. "0.1234E+02".
+func (df displayFormat) formatExp(x float64, c string) string {
+	digits, exp := mantissa(x, df.d)
+	if x == 0 {
+		exp = 0
+	}
+	str := "0." + digits + df.exponent(c, exp)
+	if math.Signbit(x) && x != 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+// formatSci formats x in the Fortran ES (scientific) form, e.g. "1.234E+01".
+func (df displayFormat) formatSci(x float64) string {
+	digits, exp := mantissa(x, df.d+1)
+	if x == 0 {
+		exp = 1
+	}
+	str := digits[:1] + "." + digits[1:] + df.exponent("E", exp-1)
+	if math.Signbit(x) && x != 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+// formatEng formats x in the Fortran EN (engineering) form, with an exponent
+// multiple of 3, e.g. "12.34E+00".
+func (df displayFormat) formatEng(x float64) string {
+	exp := 0
+	if x != 0 {
+		exp = int(math.Floor(math.Log10(math.Abs(x))))
+		exp -= ((exp % 3) + 3) % 3
+	}
+	mant := strconv.FormatFloat(math.Abs(x)/math.Pow(10, float64(exp)), 'f', df.d, 64)
+	if v, _ := strconv.ParseFloat(mant, 64); v >= 1000 {
+		exp += 3
+		mant = strconv.FormatFloat(math.Abs(x)/math.Pow(10, float64(exp)), 'f', df.d, 64)
+	}
+	str := mant + df.exponent("E", exp)
+	if math.Signbit(x) && x != 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+// formatGen formats x in the Fortran G form: as with F if 0.1 <= |x| < 10^d
+// (followed by blanks standing for the exponent), as with E otherwise.
+func (df displayFormat) formatGen(x float64) string {
+	n := 4 // width of the exponent part
+	if df.e > 0 {
+		n = df.e + 2
+	}
+	exp := 1
+	if x != 0 {
+		_, exp = mantissa(x, df.d)
+	}
+	if exp < 0 || exp > df.d {
+		return df.formatExp(x, "E")
+	}
+	return strconv.FormatFloat(x, 'f', df.d-exp, 64) + strings.Repeat(" ", n)
+}
+
+// EOF
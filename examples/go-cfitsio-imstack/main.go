@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	fits "github.com/astrogo/cfitsio"
+)
+
+func main() {
+
+	flag.Usage = func() {
+		const msg = `Usage: go-cfitsio-imstack [options] -o outfname file1 file2 [file3 ...]
+
+Co-add (stack) FITS images into a single image.
+All the input images must have the same dimensions.
+
+Examples:
+  imstack -o sum.fits a.fits b.fits c.fits         - mean of 3 images
+  imstack -m median -o sum.fits a.fits[1] b.fits[1] - median of 2 extensions
+  imstack -m sigclip -o sum.fits exp*.fits          - 3-sigma clipped mean
+
+Available methods: mean, median, sigclip, minmax.
+`
+		fmt.Fprintf(os.Stderr, "%v\n", msg)
+		flag.PrintDefaults()
+	}
+
+	outfname := flag.String("o", "out.fits", "path to stacked FITS file")
+	method := flag.String("m", "mean", "stacking method (mean|median|sigclip|minmax)")
+
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var stack fits.StackMethod
+	switch *method {
+	case "mean":
+		stack = fits.StackMean
+	case "median":
+		stack = fits.StackMedian
+	case "sigclip":
+		stack = fits.StackSigmaClip
+	case "minmax":
+		stack = fits.StackMinMax
+	default:
+		fmt.Printf("Error: invalid stacking method %q\n", *method)
+		os.Exit(1)
+	}
+
+	_, err := os.Stat(*outfname)
+	if err == nil {
+		err = os.Remove(*outfname)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	inputs := make([]*fits.ImageHDU, 0, flag.NArg())
+	for i := 0; i < flag.NArg(); i++ {
+		fname := flag.Arg(i)
+		f, err := fits.Open(fname, fits.ReadOnly)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+
+		switch hdu := f.CHDU().(type) {
+		case *fits.PrimaryHDU:
+			inputs = append(inputs, &hdu.ImageHDU)
+		case *fits.ImageHDU:
+			inputs = append(inputs, hdu)
+		default:
+			fmt.Printf("Error: [%s] is not an image\n", fname)
+			os.Exit(1)
+		}
+	}
+
+	out, err := fits.Create(*outfname)
+	if err != nil {
+		panic(err)
+	}
+	defer out.Close()
+
+	fmt.Printf("::: stacking [%d] images (method=%v)...\n", len(inputs), stack)
+	img, err := fits.Stack(&out, inputs, stack)
+	if err != nil {
+		panic(err)
+	}
+	hdr := img.Header()
+	fmt.Printf("::: stacking [%d] images (method=%v)... [done]\n", len(inputs), stack)
+	fmt.Printf("::: axes: %v\n", hdr.Axes())
+}
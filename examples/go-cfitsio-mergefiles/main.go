@@ -16,6 +16,8 @@ func main() {
 		const msg = `Usage: go-cfitsio-mergefiles -o outfname file1 file2 [file3 ...]
 
 Merge FITS tables into a single file/table.
+Input tables may have different columns: missing columns are added (and
+filled with undefined values) and numeric columns are widened as needed.
 
 `
 		fmt.Fprintf(os.Stderr, "%v\n", msg)
@@ -71,6 +73,7 @@ Merge FITS tables into a single file/table.
 	}
 
 	var table *fits.Table
+	tables := make([]*fits.Table, 0, len(infiles))
 	fmt.Printf("::: merging [%d] FITS files...\n", len(infiles))
 	for i, fname := range infiles {
 		f, err := fits.Open(fname, fits.ReadOnly)
@@ -89,19 +92,24 @@ Merge FITS tables into a single file/table.
 			}
 			defer phdu.Close()
 
-			// get schema from first input file
+			// get initial schema from first input file
 			cols := hdu.Cols()
 			table, err = fits.NewTable(&out, hdu.Name(), cols, hdu.Type())
 			if err != nil {
 				panic(err)
 			}
 			defer table.Close()
+		} else {
+			for _, diff := range table.Schema().Diff(hdu.Schema()) {
+				fmt.Printf("::: schema [%s]: %v\n", fname, diff)
+			}
 		}
+		tables = append(tables, hdu)
+	}
 
-		err = fits.CopyTable(table, hdu)
-		if err != nil {
-			panic(err)
-		}
+	err = fits.MergeTables(table, tables...)
+	if err != nil {
+		panic(err)
 	}
 	fmt.Printf("::: merging [%d] FITS files... [done]\n", len(infiles))
 	fmt.Printf("::: nrows: %d\n", table.NumRows())
@@ -428,4 +428,48 @@ func TestCreateFile(t *testing.T) {
 
 }
 
+// newTestFile creates the FITS file "new.fits", holding an empty primary HDU,
+// in a new temporary working directory.
+// The returned function closes the file and removes the directory.
+func newTestFile(t *testing.T) (*File, func()) {
+	curdir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	workdir, err := ioutil.TempDir("", "go-cfitsio-test-")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	cleanup := func() {
+		os.Chdir(curdir)
+		os.RemoveAll(workdir)
+	}
+
+	err = os.Chdir(workdir)
+	if err != nil {
+		cleanup()
+		t.Fatalf(err.Error())
+	}
+
+	fname := "new.fits"
+	f, err := Create(fname)
+	if err != nil {
+		cleanup()
+		t.Fatalf("error creating new file [%v]: %v", fname, err)
+	}
+
+	_, err = NewPrimaryHDU(&f, NewDefaultHeader())
+	if err != nil {
+		f.Close()
+		cleanup()
+		t.Fatalf("error creating PHDU: %v", err)
+	}
+
+	return &f, func() {
+		f.Close()
+		cleanup()
+	}
+}
+
 // EOF
@@ -0,0 +1,127 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import "unsafe"
+
+// Where returns an iterator over the rows for which the boolean CFITSIO
+// row filter expression expr is true, e.g. "PHA > 5 && STATUS == 0".
+// See the CFITSIO documentation for the syntax of expressions.
+func (hdu *Table) Where(expr string) (*Rows, error) {
+	idx, err := hdu.findRows(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]int, len(hdu.cols))
+	for i := range hdu.cols {
+		cols[i] = i
+	}
+
+	rows := &Rows{
+		table: hdu,
+		cols:  cols,
+		idx:   idx,
+		i:     0,
+		n:     int64(len(idx)),
+		inc:   1,
+		cur:   -1,
+		gen:   hdu.gen,
+		err:   nil,
+	}
+	return rows, err
+}
+
+// Count returns the number of rows for which the boolean CFITSIO row filter
+// expression expr is true.
+func (hdu *Table) Count(expr string) (int64, error) {
+	idx, err := hdu.findRows(expr)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(idx)), nil
+}
+
+// Select appends the rows for which the boolean CFITSIO row filter expression
+// expr is true, to the dst table.
+// dst must have the same columns than this table.
+func (hdu *Table) Select(dst *Table, expr string) error {
+	err := checkSchema("Table.Select", dst, hdu)
+	if err != nil {
+		return err
+	}
+
+	if dst.f != hdu.f {
+		return hdu.selectRows(dst, expr)
+	}
+
+	// both tables live in the same file: copy row by row
+	idx, err := hdu.findRows(expr)
+	if err != nil {
+		return err
+	}
+	return copyRows(dst, hdu, idx)
+}
+
+// selectRows appends the rows matching expr to dst with fits_select_rows.
+// dst must live in a different file.
+func (hdu *Table) selectRows(dst *Table, expr string) error {
+	err := dst.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer dst.updateNumRows()
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_expr := C.CString(expr)
+	defer C.free(unsafe.Pointer(c_expr))
+	c_status := C.int(0)
+	C.fits_select_rows(hdu.f.c, dst.f.c, c_expr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// findRows returns the (0-based) indices of the rows for which the boolean
+// expression expr is true.
+func (hdu *Table) findRows(expr string) ([]int64, error) {
+	if hdu.nrows == 0 {
+		// still validate the expression
+		_, _, _, err := hdu.testExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		return []int64{}, nil
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return nil, err
+	}
+
+	c_expr := C.CString(expr)
+	defer C.free(unsafe.Pointer(c_expr))
+	c_status := C.int(0)
+	c_ngood := C.long(0)
+	c_flags := make([]C.char, int(hdu.nrows))
+	C.fits_find_rows(hdu.f.c, c_expr, 1, C.long(hdu.nrows), &c_ngood, &c_flags[0], &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+
+	idx := make([]int64, 0, int(c_ngood))
+	for i, flag := range c_flags {
+		if flag != 0 {
+			idx = append(idx, int64(i))
+		}
+	}
+	return idx, nil
+}
+
+// EOF
@@ -31,6 +31,13 @@ char_array_set(char** array, int idx, char *value)
   array[idx] = value;
 }
 
+static
+char*
+char_array_get(char** array, int idx)
+{
+  return array[idx];
+}
+
 static
 long*
 long_array_new(int sz)
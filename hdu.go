@@ -189,6 +189,48 @@ func CopyHDU(dst, src *File, morekeys int) error {
 	return nil
 }
 
+// copyFilteredHDU opens the i-th HDU of src through the CFITSIO extended
+// filename syntax filter (e.g. "[pix X*2]" or "[bin (X,Y)=32]"), and appends
+// the resulting virtual HDU to the end of dst.
+func copyFilteredHDU(dst, src *File, i int, filter string) (HDU, error) {
+	fname, err := src.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure pending modifications are visible to the new handle
+	c_status := C.int(0)
+	C.fits_flush_file(src.c, &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+
+	c_fname := C.CString(fmt.Sprintf("%s[%d]%s", fname, i, filter))
+	defer C.free(unsafe.Pointer(c_fname))
+	var c_tmp *C.fitsfile
+	C.ffopen(&c_tmp, c_fname, C.READONLY, &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+	defer func() {
+		c_status := C.int(0)
+		C.fits_close_file(c_tmp, &c_status)
+	}()
+
+	nhdus := len(dst.hdus)
+	C.fits_copy_hdu(c_tmp, dst.c, 0, &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+
+	hdu, err := dst.readHDU(nhdus)
+	if err != nil {
+		return nil, err
+	}
+	dst.hdus = append(dst.hdus, hdu)
+	return hdu, err
+}
+
 // WriteHDU writes the current HDU in the input FITS file to the output FILE stream (e.g. stdout).
 func WriteHDU(w io.Writer, src *File) error {
 	tmp, err := ioutil.TempFile("", "go-cfitsio-")
@@ -2,6 +2,7 @@ package cfitsio
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -115,6 +116,47 @@ var g_tables = []struct {
 							Value:   0.0,
 							Comment: "THDA at end of exposure",
 						},
+						{
+							Name: "COMMENT",
+							Value: strings.Join([]string{
+								"*",
+								"* THE IUE VICAR HEADER",
+								"*",
+								"IUE-VICAR HEADER START",
+								"IUE-VICAR HEADER ENDED",
+							}, "\n"),
+						},
+						{
+							Name: "HISTORY",
+							Value: strings.Join([]string{
+								"IUE-LOG STARTED",
+								"*GEOMF   11:20Z SEP 21,'79                                            HC",
+								"*********   GEOM. & PHOTOM. CORRECTED IMAGE **********                 C",
+								"PCF C/** DATA REC. 11 1   1   1 768 8448 5 3  6.1  5.0 2536   .00000 1PC",
+								"          0       1684       3374       6873       9091      10586   1PC",
+								"      14371      17745      21524      25105      28500              1PC",
+								"     11.000     11.000     11.000     11.000     11.000     11.000   1PC",
+								"     11.000     11.000     11.000     11.000     11.000              1PC",
+								"TUBE   3 SEC EHT  6.1 ITT EHT  5.0 WAVELENGTH 2536 DIFFUSER 0        1PC",
+								"     C     MODE : FACTOR   .178E 00                                  1PC",
+								"*FICOR5   11:20Z SEP 21,'79                                           HC",
+								"********  DATA FROM LARGE APERTURE  ********                           C",
+								"*EXTLOW   11:20Z SEP 21,'79                                           HC",
+								"@EXTLOW: OMEGA=  90.0, HBACK=  5, DISTANCE= 11.0                       C",
+								"        :HT=15, DC#=   1; ISN:     0 PSN      1 SIGS=  .444 SIGL=  .421C",
+								"B 1= -.283235346667D 03 B 2=  .376096600120D 00 B 3=  .000000000000D 00C",
+								"A 1=  .964207510446D 03 A 2= -.466539532721D 00 A 3=  .000000000000D 00C",
+								"LINE SHIFT =   .000     SAMPLE SHIFT =   .000                          C",
+								"*SMOOTH   11:20Z SEP 21,'79                                           HC",
+								"*ARCHIVE   11:20Z SEP 21,'79                                          HC",
+								"*ITOE   11:20Z SEP 21,'79                                             HC",
+								"***** FILE OF MERGED EXTRACTED SPECTRA *****                           C",
+								"*** GROSS, BACKGROUND, NET & ABSOL. CALIB. NET ***                     C",
+								"*ETOEM   11:20Z SEP 21,'79                                            HC",
+								"*ARCHIVE   11:20Z SEP 21,'79                                          HL",
+								"IUE-LOG FINISHED",
+							}, "\n"),
+						},
 					},
 					IMAGE_HDU,
 					8,
@@ -169,6 +211,13 @@ var g_tables = []struct {
 							Value:   "IUE MELO",
 							Comment: "name of table (?)",
 						},
+						{
+							Name: "COMMENT",
+							Value: strings.Join([]string{
+								"  IUE MELO file data containing G, B, N, A, & E vectors",
+								"  Each row contains order number, npts, W0, deltaW, & vectors above",
+							}, "\n"),
+						},
 						{
 							Name:    "TFORM1",
 							Value:   "1I",
@@ -5,6 +5,8 @@ package cfitsio
 import "C"
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -41,12 +43,24 @@ func NewDefaultHeader() Header {
 	)
 }
 
+// AddComment adds a COMMENT record to this Header.
 func (h *Header) AddComment(v string) {
-	panic("not implemented")
+	h.addRecord("COMMENT", v)
 }
 
+// AddHistory adds a HISTORY record to this Header.
 func (h *Header) AddHistory(v string) {
-	panic("not implemented")
+	h.addRecord("HISTORY", v)
+}
+
+// addRecord appends v as a new line to the value of the commentary Card n.
+func (h *Header) addRecord(n, v string) {
+	card := h.Get(n)
+	if card == nil {
+		h.Append(Card{Name: n, Value: v})
+		return
+	}
+	card.Value = card.Value.(string) + "\n" + v
 }
 
 // Append appends a set of Cards to this Header
@@ -136,6 +150,145 @@ func (h *Header) Set(n string, v interface{}, comment string) {
 	}
 }
 
+// isStructuralKey returns whether the keyword n describes the layout of an
+// HDU (and is thus managed by CFITSIO itself.)
+func isStructuralKey(n string) bool {
+	switch n {
+	case "SIMPLE", "XTENSION", "BITPIX", "NAXIS", "EXTEND", "PCOUNT", "GCOUNT",
+		"CHECKSUM", "DATASUM", "END":
+		return true
+	}
+	if strings.HasPrefix(n, "NAXIS") {
+		_, err := strconv.Atoi(n[len("NAXIS"):])
+		return err == nil
+	}
+	return false
+}
+
+// writeCard writes (or updates) the Card card into the current HDU of file f.
+func writeCard(f *File, card *Card) error {
+	switch card.Name {
+	case "COMMENT", "HISTORY":
+		return writeRecords(f, card)
+	}
+
+	c_name := C.CString(card.Name)
+	defer C.free(unsafe.Pointer(c_name))
+	c_type := C.int(0)
+	c_status := C.int(0)
+	c_comm := C.CString(card.Comment)
+	defer C.free(unsafe.Pointer(c_comm))
+	var c_ptr unsafe.Pointer
+
+	switch v := card.Value.(type) {
+	case bool:
+		c_type = C.TLOGICAL
+		c_value := C.char(0) // 'F'
+		if v {
+			c_value = 1 // 'T'
+		}
+		c_ptr = unsafe.Pointer(&c_value)
+
+	case byte:
+		c_type = C.TBYTE
+		c_ptr = unsafe.Pointer(&v)
+
+	case uint16:
+		c_type = C.TUSHORT
+		c_ptr = unsafe.Pointer(&v)
+
+	case uint32:
+		c_type = C.TUINT
+		c_ptr = unsafe.Pointer(&v)
+
+	case uint64:
+		c_type = C.TULONG
+		c_ptr = unsafe.Pointer(&v)
+
+	case uint:
+		c_type = C.TULONG
+		c_value := C.ulong(v)
+		c_ptr = unsafe.Pointer(&c_value)
+
+	case int8:
+		c_type = C.TSBYTE
+		c_ptr = unsafe.Pointer(&v)
+
+	case int16:
+		c_type = C.TSHORT
+		c_ptr = unsafe.Pointer(&v)
+
+	case int32:
+		c_type = C.TINT
+		c_ptr = unsafe.Pointer(&v)
+
+	case int64:
+		c_type = C.TLONG
+		c_ptr = unsafe.Pointer(&v)
+
+	case int:
+		c_type = C.TLONG
+		c_value := C.long(v)
+		c_ptr = unsafe.Pointer(&c_value)
+
+	case float32:
+		c_type = C.TFLOAT
+		c_ptr = unsafe.Pointer(&v)
+
+	case float64:
+		c_type = C.TDOUBLE
+		c_ptr = unsafe.Pointer(&v)
+
+	case complex64:
+		c_type = C.TCOMPLEX
+		c_ptr = unsafe.Pointer(&v) // FIXME: assumes same memory layout than C
+
+	case complex128:
+		c_type = C.TDBLCOMPLEX
+		c_ptr = unsafe.Pointer(&v) // FIXME: assumes same memory layout than C
+
+	case string:
+		c_type = C.TSTRING
+		c_value := C.CString(v)
+		defer C.free(unsafe.Pointer(c_value))
+		c_ptr = unsafe.Pointer(c_value)
+
+	default:
+		panic(fmt.Errorf("cfitsio: invalid card type (%T)", v))
+	}
+
+	C.fits_update_key(f.c, c_type, c_name, c_ptr, c_comm, &c_status)
+
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// writeRecords writes each line of a COMMENT or HISTORY Card as a new record
+// into the current HDU of file f.
+func writeRecords(f *File, card *Card) error {
+	v, ok := card.Value.(string)
+	if !ok {
+		return fmt.Errorf("cfitsio: invalid %s card type (%T)", card.Name, card.Value)
+	}
+	for _, line := range strings.Split(v, "\n") {
+		c_line := C.CString(line)
+		defer C.free(unsafe.Pointer(c_line))
+		c_status := C.int(0)
+		switch card.Name {
+		case "COMMENT":
+			C.fits_write_comment(f.c, c_line, &c_status)
+		default:
+			C.fits_write_history(f.c, c_line, &c_status)
+		}
+		if c_status > 0 {
+			return to_err(c_status)
+		}
+	}
+	return nil
+}
+
 // readHeader returns the Header i from file f
 func readHeader(f *File, i int) (Header, error) {
 	var err error
@@ -228,6 +381,13 @@ func readHeader(f *File, i int) (Header, error) {
 		if e != nil {
 			continue
 		}
+		switch card.Name {
+		case "COMMENT", "HISTORY":
+			// gather all the records into one Card, as AddComment and
+			// AddHistory do.
+			hdr.addRecord(card.Name, card.Value.(string))
+			continue
+		}
 		hdr.Append(card)
 	}
 	return hdr, err
@@ -0,0 +1,145 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HeapInfo describes the heap of a binary table, where the values of the
+// variable length array columns are stored.
+type HeapInfo struct {
+	Start   int64 // offset of the heap from the start of the data, in bytes (THEAP)
+	Size    int64 // size of the heap, in bytes (PCOUNT)
+	Unused  int64 // number of bytes of the heap not referenced by any descriptor
+	Overlap int64 // number of bytes of the heap referenced by more than one descriptor
+	Valid   bool  // whether all the descriptors point into the heap
+}
+
+// TestHeap checks the integrity of the heap of this table.
+// Rewriting variable length arrays leaves unused bytes in the heap: see CompressHeap.
+func (hdu *Table) TestHeap() (HeapInfo, error) {
+	var info HeapInfo
+	err := hdu.seekHDU()
+	if err != nil {
+		return info, err
+	}
+
+	c_size := C.LONGLONG(0)
+	c_unused := C.LONGLONG(0)
+	c_overlap := C.LONGLONG(0)
+	c_valid := C.int(0)
+	c_status := C.int(0)
+	C.fits_test_heap(hdu.f.c, &c_size, &c_unused, &c_overlap, &c_valid, &c_status)
+	if c_status > 0 {
+		return info, to_err(c_status)
+	}
+
+	// the heap starts right after the rows, unless THEAP says otherwise
+	start := int64(hdu.rowWidth()) * hdu.nrows
+	if card := hdu.header.Get("THEAP"); card != nil {
+		if v, ok := card.Value.(int64); ok {
+			start = v
+		}
+	}
+
+	info = HeapInfo{
+		Start:   start,
+		Size:    int64(c_size),
+		Unused:  int64(c_unused),
+		Overlap: int64(c_overlap),
+		Valid:   c_valid != 0,
+	}
+	return info, nil
+}
+
+// CompressHeap removes the unused bytes from the heap of this table and
+// removes the duplicated values of variable length arrays.
+func (hdu *Table) CompressHeap() error {
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_status := C.int(0)
+	C.fits_compress_heap(hdu.f.c, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return hdu.refresh()
+}
+
+// NewTableWithHeap creates a new binary table in the given FITS file, as
+// NewTable, and reserves heap bytes in its heap (PCOUNT) for the values of
+// its variable length array columns.
+// Reserved bytes are reported as Unused by TestHeap, until CompressHeap.
+func NewTableWithHeap(f *File, name string, cols []Column, heap int64) (*Table, error) {
+	if heap < 0 {
+		return nil, fmt.Errorf("cfitsio: invalid heap size (%d)", heap)
+	}
+	table, err := NewTable(f, name, cols, BINARY_TBL)
+	if err != nil {
+		return table, err
+	}
+	if heap == 0 {
+		return table, nil
+	}
+
+	err = writeCard(f, &Card{Name: "PCOUNT", Value: heap, Comment: "&"})
+	if err != nil {
+		return table, err
+	}
+	// make CFITSIO aware of the new heap size
+	c_status := C.int(0)
+	C.fits_set_hdustruc(f.c, &c_status)
+	if c_status > 0 {
+		return table, to_err(c_status)
+	}
+	return table, table.refresh()
+}
+
+// vlaFormat applies the variable length array options (Descriptor and
+// MaxLen) to the inferred "Q" format of this Column.
+func (col *Column) vlaFormat() error {
+	switch col.Descriptor {
+	case 0, 'Q':
+		col.Descriptor = 'Q'
+	case 'P':
+		col.Format = "P" + col.Format[1:]
+	default:
+		return fmt.Errorf("cfitsio: invalid descriptor %q for column [%s]", col.Descriptor, col.Name)
+	}
+	if col.MaxLen < 0 {
+		return fmt.Errorf("cfitsio: invalid maximum length (%d) for column [%s]", col.MaxLen, col.Name)
+	}
+	if col.MaxLen > 0 {
+		col.Format += "(" + strconv.FormatInt(col.MaxLen, 10) + ")"
+	}
+	col.IsVLA = true
+	return nil
+}
+
+// parseVLA sets the variable length array fields of this Column from its
+// format, e.g. "1PE(12)".
+func (col *Column) parseVLA() {
+	_, code, err := parseTForm(col.Format)
+	if err != nil || (code[0] != 'P' && code[0] != 'Q') {
+		return
+	}
+	col.IsVLA = true
+	col.Descriptor = code[0]
+	beg := strings.Index(code, "(")
+	end := strings.Index(code, ")")
+	if beg < 0 || end < beg {
+		return
+	}
+	v, err := strconv.ParseInt(code[beg+1:end], 10, 64)
+	if err == nil {
+		col.MaxLen = v
+	}
+}
+
+// EOF
@@ -0,0 +1,110 @@
+package cfitsio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HistOptions describes how to bin the columns of a table into an image.
+// Per-axis slices are either empty or hold one value per binned column.
+type HistOptions struct {
+	Bitpix  int       // BITPIX of the image: 8, 16, 32 (default), -32 or -64
+	Min     []float64 // lower edge of each axis (default: TLMIN or the data minimum)
+	Max     []float64 // upper edge of each axis (default: TLMAX or the data maximum)
+	Binsize []float64 // bin size along each axis (default: 1)
+	Weight  string    // name of a column or keyword weighting each row (default: none)
+	Recip   bool      // whether to weight each row by the reciprocal of Weight
+	Where   string    // CFITSIO row filter expression selecting the rows to bin
+}
+
+// Histogram bins the values of the columns named cols (1 to 4 columns) into
+// an image, written as a new image HDU at the end of the file holding this table.
+// The range of an axis is used only if Min[i] < Max[i].
+// The image carries the WCS keywords mapping its pixels to the column values.
+func (hdu *Table) Histogram(cols []string, opts HistOptions) (*ImageHDU, error) {
+	naxis := len(cols)
+	if naxis < 1 || naxis > 4 {
+		return nil, fmt.Errorf("cfitsio: invalid number of histogram axes (%d)", naxis)
+	}
+	for _, n := range cols {
+		if hdu.Index(n) < 0 {
+			return nil, fmt.Errorf("cfitsio: no column named [%s]", n)
+		}
+	}
+	for _, v := range [][]float64{opts.Min, opts.Max, opts.Binsize} {
+		if len(v) != 0 && len(v) != naxis {
+			return nil, fmt.Errorf(
+				"cfitsio: invalid number of histogram parameters (got %d, want %d)",
+				len(v), naxis,
+			)
+		}
+	}
+	if len(opts.Min) != len(opts.Max) {
+		return nil, fmt.Errorf("cfitsio: histogram needs both Min and Max")
+	}
+
+	imgtype := ""
+	switch opts.Bitpix {
+	case 0:
+	case 8:
+		imgtype = "b"
+	case 16:
+		imgtype = "i"
+	case 32:
+		imgtype = "j"
+	case -32:
+		imgtype = "r"
+	case -64:
+		imgtype = "d"
+	default:
+		return nil, fmt.Errorf("cfitsio: invalid histogram BITPIX (%d)", opts.Bitpix)
+	}
+
+	fmtf := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	axes := make([]string, naxis)
+	for i, n := range cols {
+		binsize := 1.0
+		if len(opts.Binsize) > 0 {
+			binsize = opts.Binsize[i]
+		}
+		if binsize <= 0 {
+			return nil, fmt.Errorf("cfitsio: invalid histogram bin size (%v)", binsize)
+		}
+		if len(opts.Min) > 0 && opts.Min[i] < opts.Max[i] {
+			axes[i] = fmt.Sprintf("%s=%s:%s:%s", n, fmtf(opts.Min[i]), fmtf(opts.Max[i]), fmtf(binsize))
+		} else {
+			axes[i] = fmt.Sprintf("%s=%s", n, fmtf(binsize))
+		}
+	}
+
+	filter := ""
+	if opts.Where != "" {
+		filter = "[" + opts.Where + "]"
+	}
+	filter += "[bin" + imgtype + " " + strings.Join(axes, ", ")
+	if opts.Weight != "" {
+		filter += "; "
+		if opts.Recip {
+			filter += "/"
+		}
+		filter += opts.Weight
+	}
+	filter += "]"
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := copyFilteredHDU(hdu.f, hdu.f, int(hdu.id)-1, filter)
+	if err != nil {
+		return nil, err
+	}
+	return imageHDU(out), err
+}
+
+// EOF
@@ -12,6 +12,7 @@ import (
 // ImageHDU is a Header-Data-Unit extension holding an image as data payload.
 type ImageHDU struct {
 	f      *File
+	id     C.int
 	header Header
 }
 
@@ -66,7 +67,10 @@ func (hdu *ImageHDU) Data(data interface{}) error {
 
 // load loads the image data associated with this HDU into v.
 func (hdu *ImageHDU) load(v reflect.Value) error {
-	var err error
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
 	hdr := hdu.Header()
 	naxes := len(hdr.Axes())
 	if naxes == 0 {
@@ -144,6 +148,11 @@ func (hdu *ImageHDU) Write(data interface{}) error {
 		return fmt.Errorf("%T is not addressable", data)
 	}
 
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
 	hdr := hdu.Header()
 	naxes := len(hdr.Axes())
 	if naxes == 0 {
@@ -208,6 +217,149 @@ func (hdu *ImageHDU) Write(data interface{}) error {
 	return err
 }
 
+// ReadSection reads the section [beg[i], end[i]) (along each axis i) of this
+// image into data, which should be a pointer to a slice []T.
+// beg and end are 0-based pixel indices.
+// The slice is grown to the number of pixels in the section if needed.
+func (hdu *ImageHDU) ReadSection(beg, end []int64, data interface{}) error {
+	rv := reflect.ValueOf(data).Elem()
+	if !rv.CanAddr() {
+		return fmt.Errorf("%T is not addressable", data)
+	}
+
+	c_fpix, c_lpix, nelmts, err := hdu.section(beg, end)
+	if err != nil {
+		return err
+	}
+	if nelmts == 0 {
+		return nil
+	}
+
+	c_imgtype, err := imageDataType(rv.Type())
+	if err != nil {
+		return err
+	}
+	if rv.Len() < nelmts {
+		rv.Set(reflect.MakeSlice(rv.Type(), nelmts, nelmts))
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_inc := make([]C.long, len(c_fpix))
+	for i := range c_inc {
+		c_inc[i] = 1
+	}
+	c_ptr := unsafe.Pointer(rv.Pointer())
+	c_anynull := C.int(0)
+	c_status := C.int(0)
+	C.fits_read_subset(hdu.f.c, c_imgtype, &c_fpix[0], &c_lpix[0], &c_inc[0], nil, c_ptr, &c_anynull, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// WriteSection writes data, a pointer to a slice []T, into the section
+// [beg[i], end[i]) (along each axis i) of this image.
+// beg and end are 0-based pixel indices.
+func (hdu *ImageHDU) WriteSection(beg, end []int64, data interface{}) error {
+	rv := reflect.ValueOf(data).Elem()
+	if !rv.CanAddr() {
+		return fmt.Errorf("%T is not addressable", data)
+	}
+
+	c_fpix, c_lpix, nelmts, err := hdu.section(beg, end)
+	if err != nil {
+		return err
+	}
+	if nelmts == 0 {
+		return nil
+	}
+	if rv.Len() < nelmts {
+		return fmt.Errorf(
+			"cfitsio: slice too small for image section (got %d. expected %d)",
+			rv.Len(), nelmts,
+		)
+	}
+
+	c_imgtype, err := imageDataType(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	c_ptr := unsafe.Pointer(rv.Pointer())
+	c_status := C.int(0)
+	C.fits_write_subset(hdu.f.c, c_imgtype, &c_fpix[0], &c_lpix[0], c_ptr, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// section validates the 0-based [beg, end) section of this image and returns
+// the corresponding 1-based first and last pixels, and the number of pixels.
+func (hdu *ImageHDU) section(beg, end []int64) ([]C.long, []C.long, int, error) {
+	axes := hdu.header.Axes()
+	if len(beg) != len(axes) || len(end) != len(axes) {
+		return nil, nil, 0, fmt.Errorf(
+			"cfitsio: invalid image section dimensions (beg=%d, end=%d. expected %d)",
+			len(beg), len(end), len(axes),
+		)
+	}
+	c_fpix := make([]C.long, len(axes))
+	c_lpix := make([]C.long, len(axes))
+	nelmts := 1
+	for i, dim := range axes {
+		if beg[i] < 0 || end[i] > dim || beg[i] > end[i] {
+			return nil, nil, 0, fmt.Errorf(
+				"cfitsio: invalid image section [%d, %d) for axis %d (size=%d)",
+				beg[i], end[i], i+1, dim,
+			)
+		}
+		c_fpix[i] = C.long(beg[i] + 1) // 0-based to 1-based index
+		c_lpix[i] = C.long(end[i])     // inclusive 1-based index
+		nelmts *= int(end[i] - beg[i])
+	}
+	return c_fpix, c_lpix, nelmts, nil
+}
+
+// imageDataType returns the CFITSIO data type corresponding to the elements
+// of the slice type rt.
+func imageDataType(rt reflect.Type) (C.int, error) {
+	if rt.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("cfitsio: invalid image type [%v]", rt)
+	}
+	switch rt.Elem().Kind() {
+	case reflect.Uint8:
+		return C.TBYTE, nil
+	case reflect.Int8:
+		return C.TSBYTE, nil
+	case reflect.Int16:
+		return C.TSHORT, nil
+	case reflect.Uint16:
+		return C.TUSHORT, nil
+	case reflect.Int32:
+		return C.TINT, nil
+	case reflect.Uint32:
+		return C.TUINT, nil
+	case reflect.Int64:
+		return C.TLONGLONG, nil
+	case reflect.Float32:
+		return C.TFLOAT, nil
+	case reflect.Float64:
+		return C.TDOUBLE, nil
+	}
+	return 0, fmt.Errorf("cfitsio: invalid image type [%v]", rt)
+}
+
 // newImageHDU returns the i-th HDU from file f.
 // if i==0, the returned ImageHDU is actually the primary HDU.
 func newImageHDU(f *File, hdr Header, i int) (hdu HDU, err error) {
@@ -217,12 +369,142 @@ func newImageHDU(f *File, hdr Header, i int) (hdu HDU, err error) {
 	default:
 		hdu = &ImageHDU{
 			f:      f,
+			id:     C.int(i + 1), // 0-based to 1-based index
 			header: hdr,
 		}
 	}
 	return hdu, err
 }
 
+// NewImageHDU creates a new image HDU with Header hdr at the end of File f.
+// If f is still empty, the new image is created as the Primary HDU.
+// Keywords describing the structure of the HDU (SIMPLE, XTENSION, NAXISn, ...)
+// are derived from hdr.Bitpix() and hdr.Axes() and are not copied from hdr.
+func NewImageHDU(f *File, hdr Header) (*ImageHDU, error) {
+	var err error
+	nhdus := len(f.hdus)
+	if nhdus == 0 {
+		phdu, err := NewPrimaryHDU(f, hdr)
+		if err != nil {
+			return nil, err
+		}
+		return &phdu.(*PrimaryHDU).ImageHDU, nil
+	}
+
+	var c_axes *C.long
+	if len(hdr.axes) > 0 {
+		c_axes = (*C.long)(unsafe.Pointer(&hdr.axes[0]))
+	}
+	c_status := C.int(0)
+	C.fits_create_img(f.c, C.int(hdr.bitpix), C.int(len(hdr.axes)), c_axes, &c_status)
+	if c_status > 0 {
+		return nil, to_err(c_status)
+	}
+
+	for icard := range hdr.slice {
+		card := &hdr.slice[icard]
+		if isStructuralKey(card.Name) {
+			continue
+		}
+		err = writeCard(f, card)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hdu, err := f.readHDU(nhdus)
+	if err != nil {
+		return nil, err
+	}
+	f.hdus = append(f.hdus, hdu)
+
+	return hdu.(*ImageHDU), err
+}
+
+// Resize modifies the data type (bitpix) and/or the dimensions (axes) of this image.
+// If the new image is larger than the old one, the new pixels are set to zero.
+// Existing pixel values are neither converted nor re-arranged: their bytes are
+// kept as they are in the file, so a change of bitpix is mostly useful on
+// images which are about to be rewritten.
+// The cached Header is refreshed afterwards.
+func (hdu *ImageHDU) Resize(bitpix int64, axes []int64) error {
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	naxes := make([]int64, len(axes))
+	copy(naxes, axes)
+	var c_axes *C.long
+	if len(naxes) > 0 {
+		c_axes = (*C.long)(unsafe.Pointer(&naxes[0]))
+	}
+	c_status := C.int(0)
+	C.fits_resize_img(hdu.f.c, C.int(bitpix), C.int(len(naxes)), c_axes, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+
+	hdr, err := readHeader(hdu.f, int(hdu.id)-1)
+	if err != nil {
+		return err
+	}
+	hdu.header = hdr
+	return err
+}
+
+// seekHDU moves the CHDU of the underlying file to this HDU.
+func (hdu *ImageHDU) seekHDU() error {
+	c_status := C.int(0)
+	c_htype := C.int(0)
+	C.fits_movabs_hdu(hdu.f.c, hdu.id, &c_htype, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// nelmts returns the number of pixels in this image.
+func (hdu *ImageHDU) nelmts() int {
+	axes := hdu.header.Axes()
+	if len(axes) == 0 {
+		return 0
+	}
+	n := 1
+	for _, dim := range axes {
+		n *= int(dim)
+	}
+	return n
+}
+
+// float64s loads the whole image as a slice of float64 values.
+func (hdu *ImageHDU) float64s() ([]float64, error) {
+	data := make([]float64, hdu.nelmts())
+	if len(data) == 0 {
+		return data, nil
+	}
+	err := hdu.Data(&data)
+	return data, err
+}
+
+// derivedHeader returns a new Header for a floating point image derived from
+// src, with the given axes.
+// The cards describing the layout or the scaling of src are dropped.
+func derivedHeader(src Header, axes []int64) Header {
+	cards := make([]Card, 0, len(src.slice))
+	for _, card := range src.slice {
+		switch card.Name {
+		case "BSCALE", "BZERO", "BLANK":
+			continue
+		}
+		if isStructuralKey(card.Name) {
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return NewHeader(cards, IMAGE_HDU, -64, axes)
+}
+
 func init() {
 	g_hdus[IMAGE_HDU] = newImageHDU
 }
@@ -340,4 +340,169 @@ func TestImageRW(t *testing.T) {
 	}
 }
 
+func TestImageResize(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	img, err := NewImageHDU(f, NewHeader(nil, IMAGE_HDU, 16, []int64{3, 4}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	defer img.Close()
+	err = img.Resize(-32, []int64{4, 4})
+	if err != nil {
+		t.Fatalf("error resizing image: %v", err)
+	}
+
+	hdr := img.Header()
+	if hdr.Bitpix() != -32 {
+		t.Fatalf("expected BITPIX=%v. got %v", -32, hdr.Bitpix())
+	}
+	if !reflect.DeepEqual(hdr.Axes(), []int64{4, 4}) {
+		t.Fatalf("expected AXES==%v. got %v", []int64{4, 4}, hdr.Axes())
+	}
+
+	ref := []float32{
+		0, 1, 2, 3,
+		4, 5, 6, 7,
+		8, 9, 0, 1,
+		2, 3, 4, 5,
+	}
+	err = img.Write(&ref)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	data := make([]float32, len(ref))
+	err = img.Data(&data)
+	if err != nil {
+		t.Fatalf("error reading image: %v", err)
+	}
+	if !reflect.DeepEqual(data, ref) {
+		t.Fatalf("expected image:\nref=%v\ngot=%v", ref, data)
+	}
+}
+
+func TestImageRebin(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cards := []Card{
+		{"CRPIX1", 2.5, ""},
+		{"CDELT1", 0.5, ""},
+		{"CRPIX2", 4.5, ""},
+		{"CDELT2", 0.5, ""},
+	}
+	img, err := NewImageHDU(f, NewHeader(cards, IMAGE_HDU, -64, []int64{4, 4}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	defer img.Close()
+	pixels := []float64{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12,
+		13, 14, 15, 100,
+	}
+	err = img.Write(&pixels)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	for _, table := range []struct {
+		mode RebinMode
+		want []float64
+	}{
+		{
+			mode: RebinSum,
+			want: []float64{14, 22, 46, 138},
+		},
+		{
+			mode: RebinMean,
+			want: []float64{3.5, 5.5, 11.5, 34.5},
+		},
+		{
+			mode: RebinMedian,
+			want: []float64{3.5, 5.5, 11.5, 13.5},
+		},
+	} {
+		out, err := img.Rebin([]int{2, 2}, table.mode)
+		if err != nil {
+			t.Fatalf("error rebinning image (%v): %v", table.mode, err)
+		}
+
+		hdr := out.Header()
+		if !reflect.DeepEqual(hdr.Axes(), []int64{2, 2}) {
+			t.Fatalf("expected AXES==%v. got %v", []int64{2, 2}, hdr.Axes())
+		}
+
+		data := make([]float64, 4)
+		err = out.Data(&data)
+		if err != nil {
+			t.Fatalf("error reading image (%v): %v", table.mode, err)
+		}
+		if !reflect.DeepEqual(data, table.want) {
+			t.Fatalf("%v: expected image:\nref=%v\ngot=%v", table.mode, table.want, data)
+		}
+
+		for _, ref := range []Card{
+			{"CRPIX1", 1.5, ""},
+			{"CDELT1", 1.0, ""},
+			{"CRPIX2", 2.5, ""},
+			{"CDELT2", 1.0, ""},
+		} {
+			card := hdr.Get(ref.Name)
+			if card == nil {
+				t.Fatalf("error retrieving card [%v]", ref.Name)
+			}
+			if !reflect.DeepEqual(card.Value, ref.Value) {
+				t.Fatalf("card %q. expected [%v]. got [%v]", ref.Name, ref.Value, card.Value)
+			}
+		}
+	}
+
+	_, err = img.Rebin([]int{2}, RebinSum)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid number of factors")
+	}
+}
+
+func TestRebinWCS(t *testing.T) {
+	hdr := NewHeader(
+		[]Card{
+			{"CRPIX1", 2.5, ""},
+			{"CRPIX2", 4.5, ""},
+			{"PC1_1", 0.5, ""},
+			{"PC2_2", 0.5, ""},
+			{"CTYPE1A", "X", ""},
+			{"CD1_1B", 0.5, ""},
+		},
+		IMAGE_HDU, -64, []int64{4, 4},
+	)
+	rebinWCS(&hdr, []int{2, 2})
+
+	for _, ref := range []Card{
+		{"CRPIX1", 1.5, ""},
+		{"CRPIX2", 2.5, ""},
+		{"CDELT1", 2.0, ""},
+		{"CDELT2", 2.0, ""},
+		{"PC1_1", 0.5, ""},
+		{"PC2_2", 0.5, ""},
+		{"CDELT1A", 2.0, ""},
+		{"CDELT2A", 2.0, ""},
+		{"CD1_1B", 1.0, ""},
+	} {
+		card := hdr.Get(ref.Name)
+		if card == nil {
+			t.Fatalf("error retrieving card [%v]", ref.Name)
+		}
+		if !reflect.DeepEqual(card.Value, ref.Value) {
+			t.Fatalf("card %q. expected [%v]. got [%v]", ref.Name, ref.Value, card.Value)
+		}
+	}
+	if card := hdr.Get("CDELT1B"); card != nil {
+		t.Fatalf("expected no CDELT1B card with a CD matrix. got [%v]", card.Value)
+	}
+}
+
 // EOF
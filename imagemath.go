@@ -0,0 +1,148 @@
+package cfitsio
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ImageOp is a pixel-wise operation combining two images
+type ImageOp int
+
+const (
+	ImageAdd  ImageOp = iota // a + b
+	ImageSub                 // a - b
+	ImageMul                 // a * b
+	ImageDiv                 // a / b
+	ImageMask                // a where b is non-zero, NaN elsewhere
+)
+
+func (op ImageOp) String() string {
+	switch op {
+	case ImageAdd:
+		return "add"
+	case ImageSub:
+		return "sub"
+	case ImageMul:
+		return "mul"
+	case ImageDiv:
+		return "div"
+	case ImageMask:
+		return "mask"
+	default:
+		panic(fmt.Errorf("invalid ImageOp value (%v)", int(op)))
+	}
+}
+
+// Eval evaluates the CFITSIO pixel-filter expression expr on this image and
+// writes the result as a new image HDU at the end of the file holding this image.
+// expr follows the syntax of the "[pix expr]" extended filename filter:
+// the current pixel value is named X and keywords are referred to as #KEY,
+// e.g. "X*2 + #BZERO".
+// The Header of the new HDU is propagated from this image.
+func (hdu *ImageHDU) Eval(expr string) (*ImageHDU, error) {
+	err := hdu.seekHDU()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := copyFilteredHDU(hdu.f, hdu.f, int(hdu.id)-1, "[pix "+expr+"]")
+	if err != nil {
+		return nil, err
+	}
+	return imageHDU(out), err
+}
+
+// CombineImages applies the pixel-wise operation op on images a and b and
+// writes the result as a new (BITPIX=-64) image HDU at the end of dst.
+// a and b may live in different HDUs or files but must have the same dimensions.
+// The cards of a are propagated to the new HDU.
+func CombineImages(dst *File, op ImageOp, a, b *ImageHDU) (*ImageHDU, error) {
+	var err error
+	if !reflect.DeepEqual(a.header.Axes(), b.header.Axes()) {
+		return nil, fmt.Errorf(
+			"cfitsio: images dimensions differ (%v != %v)",
+			a.header.Axes(), b.header.Axes(),
+		)
+	}
+
+	xs, err := a.float64s()
+	if err != nil {
+		return nil, err
+	}
+
+	ys, err := b.float64s()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, len(xs))
+	for i, x := range xs {
+		y := ys[i]
+		switch op {
+		case ImageAdd:
+			out[i] = x + y
+		case ImageSub:
+			out[i] = x - y
+		case ImageMul:
+			out[i] = x * y
+		case ImageDiv:
+			out[i] = x / y
+		case ImageMask:
+			out[i] = x
+			if y == 0 || math.IsNaN(y) {
+				out[i] = math.NaN()
+			}
+		default:
+			return nil, fmt.Errorf("cfitsio: invalid ImageOp value (%v)", int(op))
+		}
+	}
+
+	hdu, err := NewImageHDU(dst, derivedHeader(a.header, a.header.Axes()))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > 0 {
+		err = hdu.Write(&out)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hdu, err
+}
+
+// AddImages writes a+b as a new image HDU at the end of dst.
+func AddImages(dst *File, a, b *ImageHDU) (*ImageHDU, error) {
+	return CombineImages(dst, ImageAdd, a, b)
+}
+
+// SubImages writes a-b as a new image HDU at the end of dst.
+func SubImages(dst *File, a, b *ImageHDU) (*ImageHDU, error) {
+	return CombineImages(dst, ImageSub, a, b)
+}
+
+// DivImages writes a/b as a new image HDU at the end of dst.
+// Divisions by zero follow the IEEE-754 rules (±Inf or NaN).
+func DivImages(dst *File, a, b *ImageHDU) (*ImageHDU, error) {
+	return CombineImages(dst, ImageDiv, a, b)
+}
+
+// MaskImage writes img as a new image HDU at the end of dst, where the pixels
+// for which mask is zero (or NaN) have been replaced by NaN.
+func MaskImage(dst *File, img, mask *ImageHDU) (*ImageHDU, error) {
+	return CombineImages(dst, ImageMask, img, mask)
+}
+
+// imageHDU returns the ImageHDU part of an image HDU (primary or extension)
+func imageHDU(hdu HDU) *ImageHDU {
+	switch hdu := hdu.(type) {
+	case *ImageHDU:
+		return hdu
+	case *PrimaryHDU:
+		return &hdu.ImageHDU
+	}
+	panic(fmt.Errorf("cfitsio: HDU is not an image (%T)", hdu))
+}
+
+// EOF
@@ -0,0 +1,119 @@
+package cfitsio
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestImageCombine(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	axes := []int64{2, 2}
+	a, err := NewImageHDU(f, NewHeader([]Card{{"OBJECT", "M31", ""}}, IMAGE_HDU, -64, axes))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	adata := []float64{1, 2, 3, 4}
+	err = a.Write(&adata)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	b, err := NewImageHDU(f, NewHeader(nil, IMAGE_HDU, 16, axes))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	bdata := []int16{2, 0, 1, 4}
+	err = b.Write(&bdata)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	for _, table := range []struct {
+		op   ImageOp
+		want []float64
+	}{
+		{ImageAdd, []float64{3, 2, 4, 8}},
+		{ImageSub, []float64{-1, 2, 2, 0}},
+		{ImageMul, []float64{2, 0, 3, 16}},
+		{ImageDiv, []float64{0.5, math.Inf(+1), 3, 1}},
+		{ImageMask, []float64{1, math.NaN(), 3, 4}},
+	} {
+		out, err := CombineImages(f, table.op, a, b)
+		if err != nil {
+			t.Fatalf("%v: error combining images: %v", table.op, err)
+		}
+
+		hdr := out.Header()
+		if !reflect.DeepEqual(hdr.Axes(), axes) {
+			t.Fatalf("%v: expected AXES==%v. got %v", table.op, axes, hdr.Axes())
+		}
+		card := hdr.Get("OBJECT")
+		if card == nil || card.Value != "M31" {
+			t.Fatalf("%v: expected OBJECT card to be propagated. got %v", table.op, card)
+		}
+
+		data := make([]float64, len(table.want))
+		err = out.Data(&data)
+		if err != nil {
+			t.Fatalf("%v: error reading image: %v", table.op, err)
+		}
+		for i := range data {
+			if math.IsNaN(table.want[i]) && math.IsNaN(data[i]) {
+				continue
+			}
+			if data[i] != table.want[i] {
+				t.Fatalf("%v: expected image:\nref=%v\ngot=%v", table.op, table.want, data)
+			}
+		}
+	}
+
+	c, err := NewImageHDU(f, NewHeader(nil, IMAGE_HDU, -64, []int64{4}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	_, err = AddImages(f, a, c)
+	if err == nil {
+		t.Fatalf("expected an error combining images with different dimensions")
+	}
+}
+
+func TestImageEval(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cards := []Card{{"OFFSET", int64(10), "an offset"}}
+	img, err := NewImageHDU(f, NewHeader(cards, IMAGE_HDU, -64, []int64{2, 2}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	pixels := []float64{1, 2, 3, 4}
+	err = img.Write(&pixels)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	out, err := img.Eval("X*2 + #OFFSET")
+	if err != nil {
+		t.Fatalf("error evaluating expression: %v", err)
+	}
+
+	hdr := out.Header()
+	if card := hdr.Get("OFFSET"); card == nil {
+		t.Fatalf("expected OFFSET card to be propagated")
+	}
+
+	data := make([]float64, len(pixels))
+	err = out.Data(&data)
+	if err != nil {
+		t.Fatalf("error reading image: %v", err)
+	}
+	want := []float64{12, 14, 16, 18}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("expected image:\nref=%v\ngot=%v", want, data)
+	}
+}
+
+// EOF
@@ -0,0 +1,179 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"reflect"
+	"unsafe"
+)
+
+// NullFloat64 is a float64 table cell which may be undefined (null).
+type NullFloat64 struct {
+	Float64 float64
+	Valid   bool // Valid is true if Float64 is not null
+}
+
+// NullInt64 is an int64 table cell which may be undefined (null).
+type NullInt64 struct {
+	Int64 int64
+	Valid bool // Valid is true if Int64 is not null
+}
+
+// NullString is a string table cell which may be undefined (null).
+type NullString struct {
+	String string
+	Valid  bool // Valid is true if String is not null
+}
+
+// NullBool is a bool table cell which may be undefined (null).
+type NullBool struct {
+	Bool  bool
+	Valid bool // Valid is true if Bool is not null
+}
+
+// isNullType returns whether rt is one of the Null{Float64,Int64,String,Bool} types
+func isNullType(rt reflect.Type) bool {
+	switch rt {
+	case reflect.TypeOf(NullFloat64{}),
+		reflect.TypeOf(NullInt64{}),
+		reflect.TypeOf(NullString{}),
+		reflect.TypeOf(NullBool{}):
+		return true
+	}
+	return false
+}
+
+// isCellType returns whether the struct type rt holds a single table cell,
+// as opposed to a whole row.
+func isCellType(rt reflect.Type) bool {
+	return isNullType(rt) || rt == reflect.TypeOf(BitArray{}) || rt == timeType
+}
+
+// readNull reads the cell at column icol and row irow into rv, if rv is a
+// pointer or a Null{Float64,Int64,String,Bool} value.
+// Undefined cells are read as nil pointers or as invalid Null values.
+// readNull returns false if rv is not one of these types.
+func (col *Column) readNull(f *File, icol int, irow int64, rv reflect.Value) (bool, error) {
+	rt := rv.Type()
+	if rt.Kind() != reflect.Ptr && !isNullType(rt) {
+		return false, nil
+	}
+
+	null, err := col.isNull(f, icol, irow)
+	if err != nil {
+		return true, err
+	}
+	if null {
+		rv.Set(reflect.Zero(rt))
+		return true, nil
+	}
+
+	switch rt.Kind() {
+	case reflect.Ptr:
+		v := reflect.New(rt.Elem())
+		err = col.read(f, icol, irow, v.Interface())
+		if err != nil {
+			return true, err
+		}
+		rv.Set(v)
+	default:
+		v := reflect.New(rt).Elem()
+		err = col.read(f, icol, irow, v.Field(0).Addr().Interface())
+		if err != nil {
+			return true, err
+		}
+		v.Field(1).SetBool(true)
+		rv.Set(v)
+	}
+	return true, err
+}
+
+// writeNull writes value at column icol and row irow, if value is nil, a
+// pointer or a Null{Float64,Int64,String,Bool} value.
+// nil pointers and invalid Null values are written as undefined cells
+// (TNULL for integer columns, NaN for floating point columns).
+// writeNull returns false if value is not one of these types.
+func (col *Column) writeNull(f *File, icol int, irow int64, value interface{}) (bool, error) {
+	if value == nil {
+		return true, col.writeUndefined(f, icol, irow)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Ptr:
+		if rv.IsNil() {
+			return true, col.writeUndefined(f, icol, irow)
+		}
+		return true, col.write(f, icol, irow, rv.Elem().Interface())
+
+	case isNullType(rv.Type()):
+		if !rv.Field(1).Bool() {
+			return true, col.writeUndefined(f, icol, irow)
+		}
+		return true, col.write(f, icol, irow, rv.Field(0).Interface())
+	}
+	return false, nil
+}
+
+// isNull returns whether the cell at column icol and row irow is undefined.
+func (col *Column) isNull(f *File, icol int, irow int64) (bool, error) {
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_coltype(f.c, c_icol, &c_type, &c_repeat, &c_width, &c_status)
+	if c_status > 0 {
+		return false, to_err(c_status)
+	}
+
+	c_null := C.char(0)
+	c_anynul := C.int(0)
+	switch TypeCode(c_type) {
+	case TSTRING:
+		c_value := C.CStringN(C.int(c_width) + 1)
+		defer C.free(unsafe.Pointer(c_value))
+		c_ptr := unsafe.Pointer(&c_value)
+		C.fits_read_colnull(f.c, C.TSTRING, c_icol, c_irow, 1, 1, c_ptr, &c_null, &c_anynul, &c_status)
+	case TLOGICAL:
+		c_value := C.char(0)
+		c_ptr := unsafe.Pointer(&c_value)
+		C.fits_read_colnull(f.c, C.TLOGICAL, c_icol, c_irow, 1, 1, c_ptr, &c_null, &c_anynul, &c_status)
+	default:
+		c_value := C.double(0)
+		c_ptr := unsafe.Pointer(&c_value)
+		C.fits_read_colnull(f.c, C.TDOUBLE, c_icol, c_irow, 1, 1, c_ptr, &c_null, &c_anynul, &c_status)
+	}
+	if c_status > 0 {
+		return false, to_err(c_status)
+	}
+	return c_null != 0, nil
+}
+
+// writeUndefined marks the cell at column icol and row irow as undefined.
+func (col *Column) writeUndefined(f *File, icol int, irow int64) error {
+	c_icol := C.int(icol + 1)      // 0-based to 1-based index
+	c_irow := C.LONGLONG(irow + 1) // 0-based to 1-based index
+	c_type := C.int(0)
+	c_repeat := C.long(0)
+	c_width := C.long(0)
+	c_status := C.int(0)
+	C.fits_get_coltype(f.c, c_icol, &c_type, &c_repeat, &c_width, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+
+	c_nelem := C.LONGLONG(c_repeat)
+	if TypeCode(c_type) == TSTRING || c_nelem < 1 {
+		c_nelem = 1
+	}
+	C.fits_write_col_null(f.c, c_icol, c_irow, 1, c_nelem, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// EOF
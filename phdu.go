@@ -40,6 +40,7 @@ func newPrimaryHDU(f *File, hdr Header) (HDU, error) {
 	hdu := &PrimaryHDU{
 		ImageHDU{
 			f:      f,
+			id:     1,
 			header: hdr,
 		},
 	}
@@ -63,96 +64,9 @@ func NewPrimaryHDU(f *File, hdr Header) (HDU, error) {
 	}
 
 	for icard := range hdr.slice {
-		card := &hdr.slice[icard]
-		c_name := C.CString(card.Name)
-		defer C.free(unsafe.Pointer(c_name))
-		c_type := C.int(0)
-		c_status := C.int(0)
-		c_comm := C.CString(card.Comment)
-		defer C.free(unsafe.Pointer(c_comm))
-		var c_ptr unsafe.Pointer
-
-		switch v := card.Value.(type) {
-		case bool:
-			c_type = C.TLOGICAL
-			c_value := C.char(0) // 'F'
-			if v {
-				c_value = 1 // 'T'
-			}
-			c_ptr = unsafe.Pointer(&c_value)
-
-		case byte:
-			c_type = C.TBYTE
-			c_ptr = unsafe.Pointer(&v)
-
-		case uint16:
-			c_type = C.TUSHORT
-			c_ptr = unsafe.Pointer(&v)
-
-		case uint32:
-			c_type = C.TUINT
-			c_ptr = unsafe.Pointer(&v)
-
-		case uint64:
-			c_type = C.TULONG
-			c_ptr = unsafe.Pointer(&v)
-
-		case uint:
-			c_type = C.TULONG
-			c_value := C.ulong(v)
-			c_ptr = unsafe.Pointer(&c_value)
-
-		case int8:
-			c_type = C.TSBYTE
-			c_ptr = unsafe.Pointer(&v)
-
-		case int16:
-			c_type = C.TSHORT
-			c_ptr = unsafe.Pointer(&v)
-
-		case int32:
-			c_type = C.TINT
-			c_ptr = unsafe.Pointer(&v)
-
-		case int64:
-			c_type = C.TLONG
-			c_ptr = unsafe.Pointer(&v)
-
-		case int:
-			c_type = C.TLONG
-			c_value := C.long(v)
-			c_ptr = unsafe.Pointer(&c_value)
-
-		case float32:
-			c_type = C.TFLOAT
-			c_ptr = unsafe.Pointer(&v)
-
-		case float64:
-			c_type = C.TDOUBLE
-			c_ptr = unsafe.Pointer(&v)
-
-		case complex64:
-			c_type = C.TCOMPLEX
-			c_ptr = unsafe.Pointer(&v) // FIXME: assumes same memory layout than C
-
-		case complex128:
-			c_type = C.TDBLCOMPLEX
-			c_ptr = unsafe.Pointer(&v) // FIXME: assumes same memory layout than C
-
-		case string:
-			c_type = C.TSTRING
-			c_value := C.CString(v)
-			defer C.free(unsafe.Pointer(c_value))
-			c_ptr = unsafe.Pointer(c_value)
-
-		default:
-			panic(fmt.Errorf("cfitsio: invalid card type (%T)", v))
-		}
-
-		C.fits_update_key(f.c, c_type, c_name, c_ptr, c_comm, &c_status)
-
-		if c_status > 0 {
-			return nil, to_err(c_status)
+		err = writeCard(f, &hdr.slice[icard])
+		if err != nil {
+			return nil, err
 		}
 	}
 
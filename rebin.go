@@ -0,0 +1,258 @@
+package cfitsio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// RebinMode is the block-reduction operation applied by ImageHDU.Rebin
+type RebinMode int
+
+const (
+	RebinSum    RebinMode = iota // sum of the pixels in each block
+	RebinMean                    // mean of the pixels in each block
+	RebinMedian                  // median of the pixels in each block
+)
+
+func (mode RebinMode) String() string {
+	switch mode {
+	case RebinSum:
+		return "sum"
+	case RebinMean:
+		return "mean"
+	case RebinMedian:
+		return "median"
+	default:
+		panic(fmt.Errorf("invalid RebinMode value (%v)", int(mode)))
+	}
+}
+
+// Rebin reduces this image by blocks of factors[i] pixels along each axis i,
+// and writes the result as a new (BITPIX=-64) image HDU at the end of the file
+// holding this image.
+// Trailing pixels which do not fill a whole block are dropped.
+// NaN pixels are ignored: a block only made of NaNs yields a NaN.
+// The cards of this image are propagated to the new HDU, and the WCS
+// keywords (CRPIX, CDELT, CD and PC) are updated to the new pixel grid.
+func (hdu *ImageHDU) Rebin(factors []int, mode RebinMode) (*ImageHDU, error) {
+	axes := hdu.header.Axes()
+	if len(factors) != len(axes) {
+		return nil, fmt.Errorf(
+			"cfitsio.Rebin: invalid number of factors (got %d. expected %d)",
+			len(factors), len(axes),
+		)
+	}
+
+	dims := make([]int, len(axes))
+	odims := make([]int, len(axes))
+	oaxes := make([]int64, len(axes))
+	for i, f := range factors {
+		if f <= 0 {
+			return nil, fmt.Errorf("cfitsio.Rebin: invalid factor (%d) for axis %d", f, i+1)
+		}
+		dims[i] = int(axes[i])
+		odims[i] = dims[i] / f
+		if odims[i] <= 0 {
+			return nil, fmt.Errorf(
+				"cfitsio.Rebin: factor (%d) larger than axis %d (%d)",
+				f, i+1, dims[i],
+			)
+		}
+		oaxes[i] = int64(odims[i])
+	}
+
+	data, err := hdu.float64s()
+	if err != nil {
+		return nil, err
+	}
+
+	onelmts := 1
+	for _, dim := range odims {
+		onelmts *= dim
+	}
+	out := make([]float64, onelmts)
+
+	block := make([]float64, 0, blockSize(factors))
+	oidx := make([]int, len(odims))
+	bidx := make([]int, len(factors))
+	for i := range out {
+		block = block[:0]
+		for j := range bidx {
+			bidx[j] = 0
+		}
+		for {
+			idx := 0
+			stride := 1
+			for j := range dims {
+				idx += (oidx[j]*factors[j] + bidx[j]) * stride
+				stride *= dims[j]
+			}
+			if v := data[idx]; !math.IsNaN(v) {
+				block = append(block, v)
+			}
+			if !nextIndex(bidx, factors) {
+				break
+			}
+		}
+		out[i] = reduce(block, mode)
+		nextIndex(oidx, odims)
+	}
+
+	hdr := derivedHeader(hdu.header, oaxes)
+	rebinWCS(&hdr, factors)
+
+	rebinned, err := NewImageHDU(hdu.f, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rebinned.Write(&out)
+	if err != nil {
+		return nil, err
+	}
+	return rebinned, err
+}
+
+// blockSize returns the number of pixels in a block of the given dimensions.
+func blockSize(factors []int) int {
+	n := 1
+	for _, f := range factors {
+		n *= f
+	}
+	return n
+}
+
+// nextIndex increments the multi-dimensional index idx (first axis varying
+// fastest) within dims.
+// It returns false once idx wrapped around.
+func nextIndex(idx, dims []int) bool {
+	for i := range idx {
+		idx[i]++
+		if idx[i] < dims[i] {
+			return true
+		}
+		idx[i] = 0
+	}
+	return false
+}
+
+// reduce applies the block-reduction mode to the values of a block.
+// The values may be re-ordered.
+func reduce(block []float64, mode RebinMode) float64 {
+	if len(block) == 0 {
+		return math.NaN()
+	}
+	switch mode {
+	case RebinSum, RebinMean:
+		sum := 0.0
+		for _, v := range block {
+			sum += v
+		}
+		if mode == RebinMean {
+			return sum / float64(len(block))
+		}
+		return sum
+	case RebinMedian:
+		return median(block)
+	default:
+		panic(fmt.Errorf("invalid RebinMode value (%v)", int(mode)))
+	}
+}
+
+// median returns the median of values, sorting them in place.
+func median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return 0.5 * (values[n/2-1] + values[n/2])
+}
+
+// rebinWCS updates the WCS keywords of hdr (for the primary and all the
+// alternate descriptions) after a rebinning by factors.
+func rebinWCS(hdr *Header, factors []int) {
+	alts := []string{""}
+	for c := 'A'; c <= 'Z'; c++ {
+		alts = append(alts, string(c))
+	}
+	for _, alt := range alts {
+		// CDELTi defaults to 1 with a PC matrix (or without any matrix.)
+		wcs, cd := false, false
+		for i := range factors {
+			n := strconv.Itoa(i + 1)
+			for _, key := range []string{"CTYPE", "CRPIX", "CRVAL", "CDELT"} {
+				wcs = wcs || hdr.Get(key+n+alt) != nil
+			}
+			for j := range factors {
+				m := strconv.Itoa(j + 1)
+				wcs = wcs || hdr.Get("PC"+n+"_"+m+alt) != nil
+				cd = cd || hdr.Get("CD"+n+"_"+m+alt) != nil
+			}
+		}
+
+		for i := range factors {
+			fi := float64(factors[i])
+			n := strconv.Itoa(i + 1)
+			scaleCard(hdr, "CRPIX"+n+alt, func(v float64) float64 {
+				return (v-0.5)/fi + 0.5
+			})
+			if wcs && !cd && hdr.Get("CDELT"+n+alt) == nil {
+				hdr.Set("CDELT"+n+alt, 1.0, "")
+			}
+			scaleCard(hdr, "CDELT"+n+alt, func(v float64) float64 {
+				return v * fi
+			})
+			for j := range factors {
+				fj := float64(factors[j])
+				m := strconv.Itoa(j + 1)
+				scaleCard(hdr, "CD"+n+"_"+m+alt, func(v float64) float64 {
+					return v * fj
+				})
+				scaleCard(hdr, "PC"+n+"_"+m+alt, func(v float64) float64 {
+					return v * fj / fi
+				})
+			}
+		}
+	}
+}
+
+// scaleCard replaces the numerical value v of the Card named n by fct(v).
+// It is a no-op if there is no such Card.
+func scaleCard(hdr *Header, n string, fct func(v float64) float64) {
+	card := hdr.Get(n)
+	if card == nil {
+		return
+	}
+	v, ok := cardFloat(card.Value)
+	if !ok {
+		return
+	}
+	card.Value = fct(v)
+}
+
+// cardFloat returns the value of a numerical Card as a float64
+func cardFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// EOF
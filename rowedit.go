@@ -0,0 +1,132 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"sort"
+)
+
+// InsertRows inserts n blank rows before the row at (0-based).
+// at == NumRows() appends the rows at the end of the table.
+// Rows iterators opened on this table before the insertion are invalidated.
+func (hdu *Table) InsertRows(at, n int64) error {
+	if at < 0 || at > hdu.nrows {
+		return fmt.Errorf("cfitsio: invalid row index (%d)", at)
+	}
+	if n < 0 {
+		return fmt.Errorf("cfitsio: invalid number of rows (%d)", n)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.modified()
+
+	c_status := C.int(0)
+	// fits_insert_rows inserts the new rows after the 1-based row 'at'
+	C.fits_insert_rows(hdu.f.c, C.LONGLONG(at), C.LONGLONG(n), &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// DeleteRows deletes the n rows starting at row beg (0-based).
+// Rows iterators opened on this table before the deletion are invalidated.
+func (hdu *Table) DeleteRows(beg, n int64) error {
+	if n < 0 {
+		return fmt.Errorf("cfitsio: invalid number of rows (%d)", n)
+	}
+	if beg < 0 || beg+n > hdu.nrows {
+		return fmt.Errorf("cfitsio: invalid rows range [%d, %d) (nrows=%d)", beg, beg+n, hdu.nrows)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.modified()
+
+	c_status := C.int(0)
+	C.fits_delete_rows(hdu.f.c, C.LONGLONG(beg+1), C.LONGLONG(n), &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// DeleteRowList deletes the rows whose (0-based) indices are listed in rows.
+// Rows iterators opened on this table before the deletion are invalidated.
+func (hdu *Table) DeleteRowList(rows []int64) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// fits_delete_rowlist needs a sorted list of unique row numbers
+	list := make([]int64, len(rows))
+	copy(list, rows)
+	sort.Sort(int64s(list))
+	c_rows := make([]C.long, 0, len(list))
+	for i, irow := range list {
+		if irow < 0 || irow >= hdu.nrows {
+			return fmt.Errorf("cfitsio: invalid row index (%d)", irow)
+		}
+		if i > 0 && irow == list[i-1] {
+			continue
+		}
+		c_rows = append(c_rows, C.long(irow+1)) // 0-based to 1-based index
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.modified()
+
+	c_status := C.int(0)
+	C.fits_delete_rowlist(hdu.f.c, &c_rows[0], C.long(len(c_rows)), &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// DeleteRowsWhere deletes the rows for which the boolean CFITSIO expression
+// expr is true, e.g. "X > 2 && FLAG == 0", and returns the number of
+// deleted rows.
+// Rows iterators opened on this table before the deletion are invalidated.
+func (hdu *Table) DeleteRowsWhere(expr string) (int64, error) {
+	rows, err := hdu.findRows(expr)
+	if err != nil {
+		return 0, err
+	}
+	err = hdu.DeleteRowList(rows)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+// modified updates the number of rows of this table after a structural
+// modification and invalidates the Rows iterators opened on it.
+func (hdu *Table) modified() {
+	hdu.updateNumRows()
+	hdu.gen++
+}
+
+type int64s []int64
+
+func (p int64s) Len() int           { return len(p) }
+func (p int64s) Less(i, j int) bool { return p[i] < p[j] }
+func (p int64s) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// EOF
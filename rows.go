@@ -22,11 +22,13 @@ import (
 //
 type Rows struct {
 	table  *Table
-	cols   []int // list of (active) column indices
-	i      int64 // number of rows iterated over
-	n      int64 // number of rows this iterator iters over
-	inc    int64 // number of rows to increment by at each iteration
-	cur    int64 // current row index
+	cols   []int   // list of (active) column indices
+	idx    []int64 // list of row indices to iterate over (nil to iterate over a range)
+	i      int64   // number of rows iterated over
+	n      int64   // number of rows this iterator iters over
+	inc    int64   // number of rows to increment by at each iteration
+	cur    int64   // current row index
+	gen    int64   // generation number of the table when this iterator was created
 	closed bool
 	err    error // last error
 }
@@ -50,12 +52,19 @@ func (rows *Rows) Close() error {
 
 // Scan copies the columns in the current row into the values pointed at by
 // dest.
+// Values of scaled (TSCAL/TZERO) columns are physical values, unless the
+// column is in raw mode (see Table.SetRawMode.)
 func (rows *Rows) Scan(args ...interface{}) error {
 	var err error
 	defer func() {
 		rows.err = err
 	}()
 
+	err = rows.check()
+	if err != nil {
+		return err
+	}
+
 	switch len(args) {
 	case 0:
 		// special case: read everything into the cols.
@@ -67,7 +76,9 @@ func (rows *Rows) Scan(args ...interface{}) error {
 		case reflect.Map:
 			return rows.scanMap(*args[0].(*map[string]interface{}))
 		case reflect.Struct:
-			return rows.scanStruct(args[0])
+			if !isCellType(rt) {
+				return rows.scanStruct(args[0])
+			}
 		}
 	}
 
@@ -120,14 +131,11 @@ func (rows *Rows) scanMap(data map[string]interface{}) error {
 	icols := make([]int, 0, len(data))
 	switch len(data) {
 	case 0:
-		icols = make([]int, len(rows.cols))
-		for i := range rows.cols {
-			icols[i] = i
-		}
+		icols = append(icols, rows.cols...)
 	default:
 		for k := range data {
 			icol := rows.table.Index(k)
-			if icol >= 0 {
+			if icol >= 0 && rows.active(icol) {
 				icols = append(icols, icol)
 			}
 		}
@@ -150,31 +158,56 @@ func (rows *Rows) scanStruct(data interface{}) error {
 
 	rt := reflect.TypeOf(data).Elem()
 	rv := reflect.ValueOf(data).Elem()
-	icols := make([][2]int, 0, rt.NumField())
-	for i := 0; i < rt.NumField(); i++ {
-		f := rt.Field(i)
-		n := f.Tag.Get("fits")
-		if n == "" {
-			n = f.Name
-		}
-		icol := rows.table.Index(n)
-		if icol >= 0 {
-			icols = append(icols, [2]int{i, icol})
-		}
+	icols, err := rows.table.structColumns(rt)
+	if err != nil {
+		return err
 	}
 
 	for _, icol := range icols {
+		if !rows.active(icol[1]) {
+			continue
+		}
 		col := &rows.table.cols[icol[1]]
-		err = col.read(rows.table.f, icol[1], rows.cur, &col.Value)
+		field := rv.Field(icol[0]).Addr().Interface()
+		err = col.read(rows.table.f, icol[1], rows.cur, field)
 		if err != nil {
 			return err
 		}
-		vv := reflect.ValueOf(col.Value)
-		rv.Field(icol[0]).Set(vv)
 	}
 	return err
 }
 
+// Columns returns the names of the columns this iterator reads.
+func (rows *Rows) Columns() []string {
+	if rows.table == nil {
+		return nil
+	}
+	names := make([]string, len(rows.cols))
+	for i, icol := range rows.cols {
+		names[i] = rows.table.cols[icol].Name
+	}
+	return names
+}
+
+// active returns whether the column icol is read by this iterator.
+func (rows *Rows) active(icol int) bool {
+	for _, i := range rows.cols {
+		if i == icol {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an error if rows were inserted into or deleted from the
+// table since this iterator was created.
+func (rows *Rows) check() error {
+	if rows.table != nil && rows.table.gen != rows.gen {
+		return fmt.Errorf("cfitsio: table [%s] was modified during iteration", rows.table.Name())
+	}
+	return nil
+}
+
 // Next prepares the next result row for reading with the Scan method.
 // It returns true on success, false if there is no next result row.
 // Every call to Scan, even the first one, must be preceded by a call to Next.
@@ -182,8 +215,18 @@ func (rows *Rows) Next() bool {
 	if rows.closed {
 		return false
 	}
+	if err := rows.check(); err != nil {
+		rows.err = err
+		rows.Close()
+		return false
+	}
 	next := rows.i < rows.n
-	rows.cur += rows.inc
+	switch {
+	case rows.idx == nil:
+		rows.cur += rows.inc
+	case next:
+		rows.cur = rows.idx[rows.i]
+	}
 	rows.i += rows.inc
 	if !next {
 		rows.err = rows.Close()
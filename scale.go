@@ -0,0 +1,87 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+)
+
+// SetRawMode enables (raw=true) or disables (raw=false) the TSCAL/TZERO
+// scaling of the named columns, or of all the columns if no name is given.
+//
+// In raw mode, values are read and written as stored in the file, and the
+// Value of a column has the Go type of its stored (raw) data.
+// Otherwise, values are physical values: raw*Bscale + Bzero.
+func (hdu *Table) SetRawMode(raw bool, names ...string) error {
+	icols := make([]int, 0, len(hdu.cols))
+	switch len(names) {
+	case 0:
+		for i := range hdu.cols {
+			icols = append(icols, i)
+		}
+	default:
+		for _, n := range names {
+			icol := hdu.Index(n)
+			if icol < 0 {
+				return fmt.Errorf("cfitsio: no column named [%s]", n)
+			}
+			icols = append(icols, icol)
+		}
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	for _, icol := range icols {
+		col := &hdu.cols[icol]
+		col.raw = raw
+		err = col.setScale(hdu.f, icol)
+		if err != nil {
+			return err
+		}
+		err = col.resetValue(hdu.f, icol)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// applyRawMode disables the scaling of the columns in raw mode.
+// CFITSIO resets the scaling parameters whenever an HDU is (re)loaded,
+// so this must be called after each move to this HDU.
+func (hdu *Table) applyRawMode() error {
+	for icol := range hdu.cols {
+		col := &hdu.cols[icol]
+		if !col.raw {
+			continue
+		}
+		err := col.setScale(hdu.f, icol)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setScale sets the scaling parameters CFITSIO uses for the column icol
+// (0-based) of the current HDU of file f.
+func (col *Column) setScale(f *File, icol int) error {
+	c_scale := C.double(col.Bscale)
+	c_zero := C.double(col.Bzero)
+	if col.raw {
+		c_scale = 1
+		c_zero = 0
+	}
+	c_status := C.int(0)
+	C.fits_set_tscale(f.c, C.int(icol+1), c_scale, c_zero, &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// EOF
@@ -0,0 +1,403 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ColumnSchema describes a column of a table, independently of its values.
+type ColumnSchema struct {
+	Name   string  // column name (TTYPE)
+	Format string  // column format (TFORM)
+	Unit   string  // column unit (TUNIT)
+	Dim    []int64 // column dimensions (TDIM)
+	Null   Value   // null value (TNULL)
+	Bscale float64 // scaling factor (TSCAL)
+	Bzero  float64 // scaling offset (TZERO)
+}
+
+// Schema describes the columns of a table.
+type Schema struct {
+	Columns []ColumnSchema
+}
+
+// Schema returns the description of the columns of this table.
+func (hdu *Table) Schema() Schema {
+	cols := make([]ColumnSchema, len(hdu.cols))
+	for i, col := range hdu.cols {
+		cols[i] = ColumnSchema{
+			Name:   col.Name,
+			Format: col.Format,
+			Unit:   col.Unit,
+			Dim:    col.Dim,
+			Null:   col.Null,
+			Bscale: col.Bscale,
+			Bzero:  col.Bzero,
+		}
+	}
+	return Schema{Columns: cols}
+}
+
+// Index returns the index of the column named n, or -1.
+func (s Schema) Index(n string) int {
+	for i := range s.Columns {
+		if s.Columns[i].Name == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// Equal returns whether both schemas describe the same columns, in the same order.
+func (s Schema) Equal(o Schema) bool {
+	if len(s.Columns) != len(o.Columns) {
+		return false
+	}
+	for i := range s.Columns {
+		if !s.Columns[i].Equal(o.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff returns the differences between the columns of s and o, matched by
+// name: columns of s modified or missing in o, then columns only present in o.
+// The order of the columns is not compared.
+func (s Schema) Diff(o Schema) []SchemaDiff {
+	var diffs []SchemaDiff
+	for i := range s.Columns {
+		col := &s.Columns[i]
+		j := o.Index(col.Name)
+		if j < 0 {
+			diffs = append(diffs, SchemaDiff{Name: col.Name, Old: col})
+			continue
+		}
+		if !col.Equal(o.Columns[j]) {
+			diffs = append(diffs, SchemaDiff{Name: col.Name, Old: col, New: &o.Columns[j]})
+		}
+	}
+	for j := range o.Columns {
+		col := &o.Columns[j]
+		if s.Index(col.Name) < 0 {
+			diffs = append(diffs, SchemaDiff{Name: col.Name, New: col})
+		}
+	}
+	return diffs
+}
+
+// Equal returns whether both columns have the same name, format, unit,
+// dimensions, null value and scaling.
+func (col ColumnSchema) Equal(o ColumnSchema) bool {
+	return len(col.changes(o)) == 0
+}
+
+// changes describes the differences between col and o.
+func (col ColumnSchema) changes(o ColumnSchema) []string {
+	var str []string
+	add := func(key string, a, b interface{}) {
+		str = append(str, fmt.Sprintf("%s: %v -> %v", key, a, b))
+	}
+	if col.Name != o.Name {
+		add("TTYPE", col.Name, o.Name)
+	}
+	if !sameFormat(col.Format, o.Format) {
+		add("TFORM", col.Format, o.Format)
+	}
+	if col.Unit != o.Unit {
+		add("TUNIT", col.Unit, o.Unit)
+	}
+	if len(col.Dim) > 0 || len(o.Dim) > 0 {
+		if !reflect.DeepEqual(col.Dim, o.Dim) {
+			add("TDIM", col.Dim, o.Dim)
+		}
+	}
+	if !reflect.DeepEqual(col.Null, o.Null) {
+		add("TNULL", col.Null, o.Null)
+	}
+	if col.Bscale != o.Bscale {
+		add("TSCAL", col.Bscale, o.Bscale)
+	}
+	if col.Bzero != o.Bzero {
+		add("TZERO", col.Bzero, o.Bzero)
+	}
+	return str
+}
+
+// SchemaDiff describes how a column differs between two schemas.
+type SchemaDiff struct {
+	Name string        // name of the column
+	Old  *ColumnSchema // column in the first schema (nil if missing)
+	New  *ColumnSchema // column in the second schema (nil if missing)
+}
+
+func (d SchemaDiff) String() string {
+	switch {
+	case d.Old == nil:
+		return fmt.Sprintf("+%s (%s)", d.Name, d.New.Format)
+	case d.New == nil:
+		return fmt.Sprintf("-%s (%s)", d.Name, d.Old.Format)
+	}
+	return fmt.Sprintf("~%s (%s)", d.Name, strings.Join(d.Old.changes(*d.New), ", "))
+}
+
+// MergeTables appends the rows of the srcs tables to dst.
+//
+// The tables may have different columns: the schema of dst is first extended
+// to hold the columns of all the srcs tables.
+// Missing columns are added to dst, and cells without a value are undefined
+// (NaN, TNULL or undefined logical values, or zeros and blanks for columns
+// without a null value.)
+// Numeric columns with different types are widened (e.g. "J" and "E" into "D",
+// or into "D" for columns with a non-integral scaling),
+// and string columns are widened to the longest string.
+// Columns with the same name and incompatible formats or scalings yield an error.
+func MergeTables(dst *Table, srcs ...*Table) error {
+	if dst == nil {
+		return fmt.Errorf("cfitsio: dst pointer is nil")
+	}
+	for _, src := range srcs {
+		if src == nil {
+			return fmt.Errorf("cfitsio: src pointer is nil")
+		}
+		err := dst.unify(src)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, src := range srcs {
+		err := mergeRows(dst, src)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unify extends the columns of this table to hold the columns of src.
+func (hdu *Table) unify(src *Table) error {
+	for i, col := range src.Schema().Columns {
+		icol := hdu.Index(col.Name)
+		if icol < 0 {
+			nrows := hdu.nrows
+			err := hdu.AddColumn(Column{
+				Name:    col.Name,
+				Format:  col.Format,
+				Unit:    col.Unit,
+				Null:    col.Null,
+				Bscale:  col.Bscale,
+				Bzero:   col.Bzero,
+				Display: src.cols[i].Display,
+				Dim:     col.Dim,
+			}, -1)
+			if err != nil {
+				return err
+			}
+			err = hdu.fillNull(len(hdu.cols)-1, 0, nrows)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		old := hdu.Schema().Columns[icol]
+		format, err := widenFormat(old, col)
+		if err != nil {
+			return err
+		}
+		if format == old.Format {
+			continue
+		}
+		err = hdu.ModifyColumn(old.Name, format)
+		if err != nil {
+			return err
+		}
+
+		_, code, _ := parseTForm(format)
+		if old.Null != nil && strings.IndexAny(code, "BIJK") == 0 {
+			// ModifyColumn drops the TNULL keyword
+			err = writeCard(hdu.f, &Card{Name: fmt.Sprintf("TNULL%d", icol+1), Value: old.Null})
+			if err != nil {
+				return err
+			}
+			c_status := C.int(0)
+			C.fits_set_hdustruc(hdu.f.c, &c_status)
+			if c_status > 0 {
+				return to_err(c_status)
+			}
+			err = hdu.refresh()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeRows appends the rows of src to dst, matching columns by name.
+// dst must hold all the columns of src.
+func mergeRows(dst, src *Table) error {
+	if dst.Schema().Equal(src.Schema()) {
+		defer dst.updateNumRows()
+		return copyRange(dst, src, 0, src.nrows, dst.nrows)
+	}
+
+	n := src.nrows
+	if n == 0 {
+		return nil
+	}
+	orow := dst.nrows
+	err := src.seekHDU()
+	if err != nil {
+		return err
+	}
+	nchunk := src.rowChunk()
+
+	for icol, col := range src.cols {
+		ocol := dst.Index(col.Name)
+		rt := reflect.TypeOf(col.Value)
+		if rt == nil || !isBulkType(rt) {
+			err = copyCells(dst, src, ocol, icol, 0, n, orow)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for beg := int64(0); beg < n; beg += nchunk {
+			end := beg + nchunk
+			if end > n {
+				end = n
+			}
+			rv := reflect.New(reflect.SliceOf(rt)).Elem()
+			err = src.readColumn(icol, beg, end, rv)
+			if err != nil {
+				return err
+			}
+			err = dst.writeColumn(ocol, orow+beg, rv)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for icol, col := range dst.cols {
+		if src.Index(col.Name) >= 0 {
+			continue
+		}
+		err = dst.fillNull(icol, orow, n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillNull sets the cells of rows [beg, beg+n) of column icol (0-based) to
+// undefined, if the column has a representation for undefined values.
+func (hdu *Table) fillNull(icol int, beg, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	col := &hdu.cols[icol]
+	repeat, code, err := parseTForm(col.Format)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case "E", "D", "C", "M", "L":
+	case "B", "I", "J", "K":
+		if col.Null == nil {
+			return nil
+		}
+	default:
+		// no null value for strings, bits and variable length arrays
+		return nil
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.updateNumRows()
+
+	c_status := C.int(0)
+	C.fits_write_col_null(hdu.f.c, C.int(icol+1), C.LONGLONG(beg+1), 1, C.LONGLONG(n*repeat), &c_status)
+	if c_status > 0 {
+		return to_err(c_status)
+	}
+	return nil
+}
+
+// widenFormat returns the format of a column able to hold the values of the
+// columns a and b.
+func widenFormat(a, b ColumnSchema) (string, error) {
+	if sameFormat(a.Format, b.Format) {
+		return a.Format, nil
+	}
+	incompatible := func() (string, error) {
+		return "", fmt.Errorf(
+			"cfitsio: incompatible formats for column [%s] (%q and %q)",
+			a.Name, a.Format, b.Format,
+		)
+	}
+	if a.Bscale != b.Bscale || a.Bzero != b.Bzero {
+		return incompatible()
+	}
+
+	ra, ca, err := parseTForm(a.Format)
+	if err != nil {
+		return "", err
+	}
+	rb, cb, err := parseTForm(b.Format)
+	if err != nil {
+		return "", err
+	}
+
+	if ca == "A" && cb == "A" {
+		if rb > ra {
+			ra = rb
+		}
+		return strconv.FormatInt(ra, 10) + "A", nil
+	}
+
+	desc := ""
+	if (ca[0] == 'P' || ca[0] == 'Q') && (cb[0] == 'P' || cb[0] == 'Q') {
+		// variable length arrays: widen the type of the elements
+		desc = ca[:1]
+		ca = strings.SplitN(ca[1:], "(", 2)[0]
+		cb = strings.SplitN(cb[1:], "(", 2)[0]
+	}
+	if ra != rb {
+		return incompatible()
+	}
+
+	ranks := map[string]int{"B": 1, "I": 2, "J": 3, "K": 4, "E": 5, "D": 6}
+	ia, oka := ranks[ca]
+	ib, okb := ranks[cb]
+	if !oka || !okb {
+		return incompatible()
+	}
+	if ib > ia {
+		ia, ib = ib, ia
+		ca, cb = cb, ca
+	}
+	code := ca
+	switch {
+	case ca == "E" && (cb == "J" || cb == "K"):
+		// float32 can not hold 32b and 64b integers
+		code = "D"
+	case a.Bscale != 0 && a.Bscale != 1 || a.Bzero != math.Trunc(a.Bzero):
+		// ModifyColumn drops TSCAL and TZERO: store the physical values
+		code = "D"
+	}
+	return strconv.FormatInt(ra, 10) + desc + code, nil
+}
+
+// EOF
@@ -0,0 +1,217 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// SortKey describes a column to sort a table by.
+type SortKey struct {
+	Name string // column name
+	Desc bool   // whether to sort in descending order
+}
+
+// Sort sorts the rows of the table in place, according to the values of the
+// columns described by keys: rows are ordered by the first key, then by the
+// second one for equal values of the first key, etc...
+// Sort is stable: rows with equal keys keep their relative order.
+//
+// Only the key columns and a rows index are loaded in memory: rows are then
+// permuted in the file, one at a time.
+func (hdu *Table) Sort(keys ...SortKey) error {
+	perm, err := hdu.sortIndex(keys)
+	if err != nil {
+		return err
+	}
+
+	err = hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	// apply the permutation, following its cycles:
+	// the row at index i must receive the row at index perm[i].
+	width := hdu.rowWidth()
+	tmp := make([]byte, width)
+	buf := make([]byte, width)
+	done := make([]bool, len(perm))
+	for beg := range perm {
+		if done[beg] || perm[beg] == int64(beg) {
+			continue
+		}
+		err = hdu.readRowBytes(int64(beg), tmp)
+		if err != nil {
+			return err
+		}
+		i := int64(beg)
+		for {
+			done[i] = true
+			j := perm[i]
+			if j == int64(beg) {
+				err = hdu.writeRowBytes(i, tmp)
+				if err != nil {
+					return err
+				}
+				break
+			}
+			err = hdu.readRowBytes(j, buf)
+			if err != nil {
+				return err
+			}
+			err = hdu.writeRowBytes(i, buf)
+			if err != nil {
+				return err
+			}
+			i = j
+		}
+	}
+	return nil
+}
+
+// SortTo appends the rows of the table, sorted according to keys (see Sort),
+// to the dst table.
+// dst must have the same columns than this table.
+func (hdu *Table) SortTo(dst *Table, keys ...SortKey) error {
+	err := checkSchema("Table.SortTo", dst, hdu)
+	if err != nil {
+		return err
+	}
+
+	perm, err := hdu.sortIndex(keys)
+	if err != nil {
+		return err
+	}
+	return copyRows(dst, hdu, perm)
+}
+
+// sortIndex returns the indices of the rows of the table, sorted according to keys.
+func (hdu *Table) sortIndex(keys []SortKey) ([]int64, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("cfitsio: no sort key")
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &rowSorter{
+		idx:  make([]int64, int(hdu.nrows)),
+		keys: make([]func(i, j int64) int, len(keys)),
+	}
+	for i := range s.idx {
+		s.idx[i] = int64(i)
+	}
+
+	for i, key := range keys {
+		icol := hdu.Index(key.Name)
+		if icol < 0 {
+			return nil, fmt.Errorf("cfitsio: no column named [%s]", key.Name)
+		}
+		tcode, repeat, width, err := hdu.colType(icol)
+		if err != nil {
+			return nil, err
+		}
+
+		col := &hdu.cols[icol]
+		scaled := col.Bscale != 0 && col.Bscale != 1 || col.Bzero != math.Trunc(col.Bzero)
+
+		var rt reflect.Type
+		switch {
+		case tcode == TSTRING && stringsPerRow(repeat, width) == 1:
+			rt = reflect.TypeOf([]string(nil))
+		case tcode < 0 || tcode == TSTRING || repeat != 1:
+			return nil, fmt.Errorf("cfitsio: can not sort by vector column [%s]", key.Name)
+		case tcode == TLOGICAL:
+			rt = reflect.TypeOf([]bool(nil))
+		case tcode == TFLOAT || tcode == TDOUBLE || scaled:
+			rt = reflect.TypeOf([]float64(nil))
+		case tcode == TLONGLONG && col.Bzero == 1<<63 && !col.raw:
+			// unsigned 64-bit integers do not fit in an int64.
+			rt = reflect.TypeOf([]uint64(nil))
+		default:
+			rt = reflect.TypeOf([]int64(nil))
+		}
+		rv := reflect.New(rt).Elem()
+		err = hdu.readColumn(icol, 0, hdu.nrows, rv)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[i] = keyCompare(rv, key.Desc)
+	}
+
+	sort.Stable(s)
+	return s.idx, nil
+}
+
+// rowSorter sorts row indices according to the values of key columns.
+type rowSorter struct {
+	idx  []int64                // row indices
+	keys []func(i, j int64) int // comparison of rows i and j, for each key
+}
+
+func (s *rowSorter) Len() int      { return len(s.idx) }
+func (s *rowSorter) Swap(i, j int) { s.idx[i], s.idx[j] = s.idx[j], s.idx[i] }
+
+func (s *rowSorter) Less(i, j int) bool {
+	ri := s.idx[i]
+	rj := s.idx[j]
+	for _, cmp := range s.keys {
+		if c := cmp(ri, rj); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+// keyCompare returns the comparison function of the rows of a key column,
+// given its values rv (indexed by row) and sort order.
+func keyCompare(rv reflect.Value, desc bool) func(i, j int64) int {
+	sign := +1
+	if desc {
+		sign = -1
+	}
+	switch v := rv.Interface().(type) {
+	case []string:
+		return func(i, j int64) int { return sign * compare(v[i] < v[j], v[i] > v[j]) }
+	case []bool:
+		return func(i, j int64) int { return sign * compare(!v[i] && v[j], v[i] && !v[j]) }
+	case []float64:
+		return func(i, j int64) int {
+			// NaNs come last, whatever the sort order.
+			ni, nj := math.IsNaN(v[i]), math.IsNaN(v[j])
+			switch {
+			case ni && nj:
+				return 0
+			case ni:
+				return +1
+			case nj:
+				return -1
+			}
+			return sign * compare(v[i] < v[j], v[i] > v[j])
+		}
+	case []uint64:
+		return func(i, j int64) int { return sign * compare(v[i] < v[j], v[i] > v[j]) }
+	case []int64:
+		return func(i, j int64) int { return sign * compare(v[i] < v[j], v[i] > v[j]) }
+	}
+	panic(fmt.Errorf("cfitsio: invalid sort key type [%T]", rv.Interface()))
+}
+
+// compare returns -1 if less, +1 if greater and 0 otherwise.
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return +1
+	}
+	return 0
+}
+
+// EOF
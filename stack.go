@@ -0,0 +1,203 @@
+package cfitsio
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// StackMethod is the pixel-wise combination applied by Stack
+type StackMethod int
+
+const (
+	StackMean      StackMethod = iota // mean of the input pixels
+	StackMedian                       // median of the input pixels
+	StackSigmaClip                    // mean after iterative 3-sigma clipping
+	StackMinMax                       // mean after rejection of the minimum and maximum
+)
+
+func (method StackMethod) String() string {
+	switch method {
+	case StackMean:
+		return "mean"
+	case StackMedian:
+		return "median"
+	case StackSigmaClip:
+		return "sigclip"
+	case StackMinMax:
+		return "minmax"
+	default:
+		panic(fmt.Errorf("invalid StackMethod value (%v)", int(method)))
+	}
+}
+
+const (
+	stackBufSize  = 1 << 20 // maximum number of input pixels held in memory
+	stackClipSig  = 3.0     // sigma-clipping threshold
+	stackClipIter = 5       // maximum number of sigma-clipping iterations
+)
+
+// Stack combines the pixels of the inputs images with method and writes the
+// result as a new (BITPIX=-64) image HDU at the end of dst.
+// All the inputs must have the same dimensions.
+// Inputs are streamed section by section (along their last axis) so the
+// whole set of images is never held in memory.
+// NaN pixels are ignored.
+// The cards of the first input are propagated to the new HDU, together with
+// a HISTORY trail of the combined inputs.
+func Stack(dst *File, inputs []*ImageHDU, method StackMethod) (*ImageHDU, error) {
+	var err error
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("cfitsio.Stack: no input image")
+	}
+
+	axes := inputs[0].header.Axes()
+	if len(axes) == 0 {
+		return nil, fmt.Errorf("cfitsio.Stack: input image has no data")
+	}
+	for i, in := range inputs[1:] {
+		if !reflect.DeepEqual(in.header.Axes(), axes) {
+			return nil, fmt.Errorf(
+				"cfitsio.Stack: input #%d dimensions differ (%v != %v)",
+				i+1, in.header.Axes(), axes,
+			)
+		}
+	}
+
+	hdr := derivedHeader(inputs[0].header, axes)
+	hdr.AddHistory(fmt.Sprintf("stack of %d images (method=%v)", len(inputs), method))
+	for i, in := range inputs {
+		fname, err := in.f.Name()
+		if err != nil {
+			return nil, err
+		}
+		hdr.AddHistory(fmt.Sprintf("input #%d: %s[%d]", i, fname, int(in.id)-1))
+	}
+
+	out, err := NewImageHDU(dst, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	// number of pixels in a slice along the last axis
+	last := len(axes) - 1
+	plane := int64(1)
+	for _, dim := range axes[:last] {
+		plane *= dim
+	}
+	chunk := int64(stackBufSize) / (plane * int64(len(inputs)))
+	if chunk < 1 {
+		chunk = 1
+	}
+
+	beg := make([]int64, len(axes))
+	end := make([]int64, len(axes))
+	copy(end, axes)
+	bufs := make([][]float64, len(inputs))
+	values := make([]float64, 0, len(inputs))
+	for lo := int64(0); lo < axes[last]; lo += chunk {
+		hi := lo + chunk
+		if hi > axes[last] {
+			hi = axes[last]
+		}
+		beg[last] = lo
+		end[last] = hi
+
+		for i, in := range inputs {
+			err = in.ReadSection(beg, end, &bufs[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		n := int(plane * (hi - lo))
+		res := make([]float64, n)
+		for j := 0; j < n; j++ {
+			values = values[:0]
+			for i := range bufs {
+				if v := bufs[i][j]; !math.IsNaN(v) {
+					values = append(values, v)
+				}
+			}
+			res[j] = combine(values, method)
+		}
+
+		err = out.WriteSection(beg, end, &res)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, err
+}
+
+// combine applies the stacking method to the values of a pixel.
+// The values may be re-ordered.
+func combine(values []float64, method StackMethod) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	switch method {
+	case StackMean:
+		return mean(values)
+	case StackMedian:
+		return median(values)
+	case StackSigmaClip:
+		for iter := 0; iter < stackClipIter; iter++ {
+			m := mean(values)
+			std := 0.0
+			for _, v := range values {
+				std += (v - m) * (v - m)
+			}
+			std = math.Sqrt(std / float64(len(values)))
+			kept := values[:0]
+			for _, v := range values {
+				if math.Abs(v-m) <= stackClipSig*std {
+					kept = append(kept, v)
+				}
+			}
+			if len(kept) == len(values) || len(kept) == 0 {
+				break
+			}
+			values = kept
+		}
+		return mean(values)
+	case StackMinMax:
+		if len(values) <= 2 {
+			return mean(values)
+		}
+		imin, imax := 0, 0
+		for i, v := range values {
+			if v < values[imin] {
+				imin = i
+			}
+			if v > values[imax] {
+				imax = i
+			}
+		}
+		if imin == imax {
+			imax = (imin + 1) % len(values)
+		}
+		sum := 0.0
+		for i, v := range values {
+			if i == imin || i == imax {
+				continue
+			}
+			sum += v
+		}
+		return sum / float64(len(values)-2)
+	default:
+		panic(fmt.Errorf("invalid StackMethod value (%v)", int(method)))
+	}
+}
+
+// mean returns the arithmetic mean of values.
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// EOF
@@ -0,0 +1,118 @@
+package cfitsio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImageSection(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	img, err := NewImageHDU(f, NewHeader(nil, IMAGE_HDU, 32, []int64{4, 3}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	pixels := []int32{
+		0, 1, 2, 3,
+		4, 5, 6, 7,
+		8, 9, 10, 11,
+	}
+	err = img.Write(&pixels)
+	if err != nil {
+		t.Fatalf("error writing image: %v", err)
+	}
+
+	var data []int32
+	err = img.ReadSection([]int64{1, 1}, []int64{3, 3}, &data)
+	if err != nil {
+		t.Fatalf("error reading section: %v", err)
+	}
+	want := []int32{5, 6, 9, 10}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("expected section:\nref=%v\ngot=%v", want, data)
+	}
+
+	section := []int32{-1, -2}
+	err = img.WriteSection([]int64{0, 2}, []int64{2, 3}, &section)
+	if err != nil {
+		t.Fatalf("error writing section: %v", err)
+	}
+	all := make([]int32, len(pixels))
+	err = img.Data(&all)
+	if err != nil {
+		t.Fatalf("error reading image: %v", err)
+	}
+	want = []int32{
+		0, 1, 2, 3,
+		4, 5, 6, 7,
+		-1, -2, 10, 11,
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("expected image:\nref=%v\ngot=%v", want, all)
+	}
+
+	err = img.ReadSection([]int64{0, 0}, []int64{5, 1}, &data)
+	if err == nil {
+		t.Fatalf("expected an error for an out-of-bounds section")
+	}
+}
+
+func TestStack(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	// 12 inputs: pixel #0 holds an outlier in the last image,
+	// pixel #1 holds the index of the image.
+	images := make([][]float64, 12)
+	for i := range images {
+		images[i] = []float64{1, float64(i), 2, 3}
+	}
+	images[len(images)-1][0] = 100
+
+	inputs := make([]*ImageHDU, 0, len(images))
+	for i := range images {
+		img, err := NewImageHDU(f, NewHeader(nil, IMAGE_HDU, -64, []int64{2, 2}))
+		if err != nil {
+			t.Fatalf("error creating image: %v", err)
+		}
+		err = img.Write(&images[i])
+		if err != nil {
+			t.Fatalf("error writing image: %v", err)
+		}
+		inputs = append(inputs, img)
+	}
+
+	for _, table := range []struct {
+		method StackMethod
+		want   []float64
+	}{
+		{StackMean, []float64{9.25, 5.5, 2, 3}},
+		{StackMedian, []float64{1, 5.5, 2, 3}},
+		{StackSigmaClip, []float64{1, 5.5, 2, 3}},
+		{StackMinMax, []float64{1, 5.5, 2, 3}},
+	} {
+		out, err := Stack(f, inputs, table.method)
+		if err != nil {
+			t.Fatalf("%v: error stacking images: %v", table.method, err)
+		}
+
+		data := make([]float64, 4)
+		err = out.Data(&data)
+		if err != nil {
+			t.Fatalf("%v: error reading image: %v", table.method, err)
+		}
+		if !reflect.DeepEqual(data, table.want) {
+			t.Fatalf("%v: expected image:\nref=%v\ngot=%v", table.method, table.want, data)
+		}
+
+		hdr := out.Header()
+		hist := hdr.History()
+		if n := strings.Count(hist, "input #"); n != len(inputs) {
+			t.Fatalf("%v: expected %d inputs in HISTORY. got %d:\n%s", table.method, len(inputs), n, hist)
+		}
+	}
+}
+
+// EOF
@@ -0,0 +1,384 @@
+package cfitsio
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// structField describes how a struct field maps to a table column.
+type structField struct {
+	index  int     // index of the field in its struct
+	name   string  // column name (TTYPE)
+	unit   string  // column unit (TUNIT)
+	format string  // column format (TFORM)
+	disp   string  // display format (TDISP)
+	null   string  // null value (TNULL)
+	scale  float64 // scaling factor (TSCAL)
+	zero   float64 // offset (TZERO)
+	dim    []int64 // column dimensions (TDIM)
+}
+
+// structFields returns the list of fields of the struct type rt mapped to
+// table columns.
+func structFields(rt reflect.Type) ([]structField, error) {
+	fields := make([]structField, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		tag := f.Tag.Get("fits")
+		if tag == "-" {
+			continue
+		}
+		field, err := parseStructTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("cfitsio: invalid struct tag for field [%s]: %v", f.Name, err)
+		}
+		field.index = i
+		if field.name == "" {
+			field.name = f.Name
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseStructTag parses a `fits:"name,opt=value,..."` struct tag.
+func parseStructTag(tag string) (structField, error) {
+	var field structField
+	toks := splitTag(tag)
+	if len(toks) == 0 {
+		return field, nil
+	}
+	field.name = strings.TrimSpace(toks[0])
+	for _, tok := range toks[1:] {
+		kv := strings.SplitN(tok, "=", 2)
+		if len(kv) != 2 {
+			return field, fmt.Errorf("invalid option %q", tok)
+		}
+		k := strings.TrimSpace(kv[0])
+		v := strings.TrimSpace(kv[1])
+		var err error
+		switch k {
+		case "unit":
+			field.unit = v
+		case "format":
+			field.format = v
+		case "disp":
+			field.disp = v
+		case "null":
+			field.null = v
+		case "scale":
+			field.scale, err = strconv.ParseFloat(v, 64)
+		case "zero":
+			field.zero, err = strconv.ParseFloat(v, 64)
+		case "dim":
+			field.dim, err = parseDims(v)
+		default:
+			err = fmt.Errorf("unknown option %q", k)
+		}
+		if err != nil {
+			return field, err
+		}
+	}
+	return field, nil
+}
+
+// splitTag splits a struct tag on commas, except for those within parentheses.
+func splitTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	toks := make([]string, 0, 2)
+	depth := 0
+	beg := 0
+	for i, c := range tag {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				toks = append(toks, tag[beg:i])
+				beg = i + 1
+			}
+		}
+	}
+	return append(toks, tag[beg:])
+}
+
+// parseDims parses a TDIM value, e.g. "(3,2)".
+func parseDims(v string) ([]int64, error) {
+	v = strings.Replace(v, "(", "", -1)
+	v = strings.Replace(v, ")", "", -1)
+	dims := make([]int64, 0, 2)
+	for _, tok := range strings.Split(v, ",") {
+		tok = strings.Trim(tok, " \t\n")
+		if tok == "" {
+			continue
+		}
+		dim, err := strconv.ParseInt(tok, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		dims = append(dims, dim)
+	}
+	return dims, nil
+}
+
+// column returns the Column description of this field, according to the
+// field value v and the table type htype.
+func (field *structField) column(v reflect.Value, htype HDUType) (Column, error) {
+	col := Column{
+		Name:    field.name,
+		Format:  field.format,
+		Unit:    field.unit,
+		Bscale:  field.scale,
+		Bzero:   field.zero,
+		Display: field.disp,
+		Dim:     field.dim,
+	}
+
+	switch {
+	case v.Kind() == reflect.Ptr:
+		col.Value = reflect.Zero(v.Type().Elem()).Interface()
+	case isNullType(v.Type()):
+		col.Value = v.Field(0).Interface()
+	default:
+		col.Value = v.Interface()
+	}
+
+	if field.null != "" {
+		switch htype {
+		case ASCII_TBL:
+			col.Null = field.null
+		default:
+			null, err := strconv.ParseInt(field.null, 10, 64)
+			if err != nil {
+				return col, fmt.Errorf("cfitsio: invalid null value for column [%s]: %v", col.Name, err)
+			}
+			col.Null = null
+		}
+	}
+	return col, nil
+}
+
+// structColumns returns the (field index, column index) pairs of the fields of
+// the struct type rt mapped to a column of this table.
+func (hdu *Table) structColumns(rt reflect.Type) ([][2]int, error) {
+	fields, err := structFields(rt)
+	if err != nil {
+		return nil, err
+	}
+	icols := make([][2]int, 0, len(fields))
+	for _, field := range fields {
+		icol := hdu.Index(field.name)
+		if icol >= 0 {
+			icols = append(icols, [2]int{field.index, icol})
+		}
+	}
+	return icols, nil
+}
+
+// typedField maps a field of a struct to a column of a table.
+type typedField struct {
+	index int  // index of the field in the struct
+	icol  int  // index of the column in the table
+	bulk  bool // whether the field can be read/written with bulk column I/O
+}
+
+// typedFields returns the mapping between the fields of the struct type rt
+// and the columns of table.
+func typedFields(table *Table, rt reflect.Type) ([]typedField, error) {
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cfitsio: invalid row type %v (expected a struct)", rt)
+	}
+	icols, err := table.structColumns(rt)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]typedField, len(icols))
+	for i, icol := range icols {
+		fields[i] = typedField{
+			index: icol[0],
+			icol:  icol[1],
+			bulk:  isBulkType(rt.Field(icol[0]).Type),
+		}
+	}
+	return fields, nil
+}
+
+// isBulkType returns whether values of type rt can be read and written with
+// ReadColumn and WriteColumn.
+// Pointers, Null types, BitArrays and nested slices are handled cell by cell.
+func isBulkType(rt reflect.Type) bool {
+	switch rt.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128,
+		reflect.String, reflect.Array:
+		return true
+	case reflect.Slice:
+		return rt.Elem().Kind() != reflect.Slice
+	}
+	return false
+}
+
+// checkCell checks that a value of type rt fills exactly one cell of the
+// column icol (0-based).
+func (hdu *Table) checkCell(icol int, rt reflect.Type) error {
+	tcode, repeat, _, err := hdu.colType(icol)
+	if err != nil {
+		return err
+	}
+	if tcode < 0 || rt.Kind() == reflect.String || rt.Kind() == reflect.Slice {
+		return nil
+	}
+	dims, _ := arrayShape(rt)
+	if nelements(dims) != repeat {
+		return fmt.Errorf(
+			"cfitsio: column [%s] holds %d values per row (got %v)",
+			hdu.cols[icol].Name, repeat, rt,
+		)
+	}
+	return nil
+}
+
+// readStructs reads the rows [beg, end) into rv, a slice of structs with
+// end-beg elements, according to the fields mapping.
+func (hdu *Table) readStructs(fields []typedField, beg, end int64, rv reflect.Value) error {
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if !field.bulk {
+			col := &hdu.cols[field.icol]
+			for i := 0; i < rv.Len(); i++ {
+				ptr := rv.Index(i).Field(field.index).Addr().Interface()
+				err = col.read(hdu.f, field.icol, beg+int64(i), ptr)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		ft := rv.Type().Elem().Field(field.index).Type
+		buf := reflect.New(reflect.SliceOf(ft)).Elem()
+		err = hdu.readColumn(field.icol, beg, end, buf)
+		if err != nil {
+			return err
+		}
+		if buf.Len() != rv.Len() {
+			return fmt.Errorf(
+				"cfitsio: column [%s] can not be read into a field of type %v",
+				hdu.cols[field.icol].Name, ft,
+			)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			rv.Index(i).Field(field.index).Set(buf.Index(i))
+		}
+	}
+	return nil
+}
+
+// writeStructs writes rv, a slice of structs, starting at row beg,
+// according to the fields mapping.
+func (hdu *Table) writeStructs(fields []typedField, beg int64, rv reflect.Value) error {
+	if rv.Len() == 0 {
+		return nil
+	}
+	if beg < 0 {
+		return fmt.Errorf("cfitsio: invalid row index (%d)", beg)
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+	defer hdu.updateNumRows()
+
+	for _, field := range fields {
+		if !field.bulk {
+			col := &hdu.cols[field.icol]
+			for i := 0; i < rv.Len(); i++ {
+				value := rv.Index(i).Field(field.index).Interface()
+				err = col.write(hdu.f, field.icol, beg+int64(i), value)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		ft := rv.Type().Elem().Field(field.index).Type
+		err = hdu.checkCell(field.icol, ft)
+		if err != nil {
+			return err
+		}
+		buf := reflect.MakeSlice(reflect.SliceOf(ft), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			buf.Index(i).Set(rv.Index(i).Field(field.index))
+		}
+		err = hdu.writeColumn(field.icol, beg, buf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewTableFromStruct creates a new table in the given FITS file, with the
+// columns described by the fields of the struct (or pointer to struct) proto.
+//
+// Columns are named after the `fits` struct tag of each field, or after the
+// field name if the tag is missing or has an empty name. The tag may also hold the unit, format (TFORM), disp (TDISP),
+// null (TNULL), scale (TSCAL), zero (TZERO) and dim (TDIM) of the column:
+//
+//	type Row struct {
+//	    ID   int64     `fits:"id"`
+//	    Flux float32   `fits:"flux,unit=Jy,null=-999,format=1J,scale=0.01"`
+//	    Pix  [2][3]int `fits:"pix,dim=(3,2),disp=I4"`
+//	    Tmp  float64   `fits:"-"`
+//	}
+//	table, err := cfitsio.NewTableFromStruct(f, "data", Row{}, cfitsio.BINARY_TBL)
+//
+// Fields tagged `fits:"-"` and unexported fields are skipped.
+// The values of the fields of proto are used to infer the formats of the
+// columns, e.g. for BitArray fields.
+func NewTableFromStruct(f *File, name string, proto interface{}, hdutype HDUType) (*Table, error) {
+	rv := reflect.ValueOf(proto)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cfitsio.NewTableFromStruct: invalid proto type (%T)", proto)
+	}
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]Column, 0, len(fields))
+	for i := range fields {
+		field := &fields[i]
+		col, err := field.column(rv.Field(field.index), hdutype)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+
+	return NewTable(f, name, cols, hdutype)
+}
+
+// EOF
@@ -6,8 +6,6 @@ import "C"
 import (
 	"fmt"
 	"reflect"
-	"strconv"
-	"strings"
 	"unsafe"
 )
 
@@ -28,6 +26,7 @@ const (
 	TINT32BIT   TypeCode = C.TINT32BIT /* used when returning datatype of a column */
 	TFLOAT      TypeCode = C.TFLOAT
 	TLONGLONG   TypeCode = C.TLONGLONG
+	TULONGLONG  TypeCode = C.TULONGLONG
 	TDOUBLE     TypeCode = C.TDOUBLE
 	TCOMPLEX    TypeCode = C.TCOMPLEX
 	TDBLCOMPLEX TypeCode = C.TDBLCOMPLEX
@@ -47,6 +46,7 @@ const (
 	TVLAINT32BIT   TypeCode = -C.TINT32BIT /* used when returning datatype of a column */
 	TVLAFLOAT      TypeCode = -C.TFLOAT
 	TVLALONGLONG   TypeCode = -C.TLONGLONG
+	TVLAULONGLONG  TypeCode = -C.TULONGLONG
 	TVLADOUBLE     TypeCode = -C.TDOUBLE
 	TVLACOMPLEX    TypeCode = -C.TCOMPLEX
 	TVLADBLCOMPLEX TypeCode = -C.TDBLCOMPLEX
@@ -55,7 +55,10 @@ const (
 func govalue_from_typecode(t TypeCode) Value {
 	var v Value
 	switch t {
-	case TBIT, TBYTE:
+	case TBIT:
+		v = BitArray{}
+
+	case TBYTE:
 		var vv byte
 		v = vv
 
@@ -103,6 +106,10 @@ func govalue_from_typecode(t TypeCode) Value {
 		var vv int64
 		v = vv
 
+	case TULONGLONG:
+		var vv uint64
+		v = vv
+
 	case TDOUBLE:
 		var vv float64
 		v = vv
@@ -115,7 +122,10 @@ func govalue_from_typecode(t TypeCode) Value {
 		var vv complex128
 		v = vv
 
-	case TVLABIT, TVLABYTE:
+	case TVLABIT:
+		v = BitArray{}
+
+	case TVLABYTE:
 		var vv = make([]byte, 0)
 		v = vv
 
@@ -163,6 +173,10 @@ func govalue_from_typecode(t TypeCode) Value {
 		var vv = make([]int64, 0)
 		v = vv
 
+	case TVLAULONGLONG:
+		var vv = make([]uint64, 0)
+		v = vv
+
 	case TVLADOUBLE:
 		var vv = make([]float64, 0)
 		v = vv
@@ -187,7 +201,7 @@ type Table struct {
 	header Header
 	nrows  int64
 	cols   []Column
-	data   interface{}
+	gen    int64 // generation number, incremented when rows are inserted or deleted
 }
 
 func (hdu *Table) Close() error {
@@ -219,15 +233,92 @@ func (hdu *Table) Version() int {
 	return card.Value.(int)
 }
 
-func (hdu *Table) Data(interface{}) error {
-	var err error
-	if hdu.data == nil {
-		err = hdu.load()
+// Data loads the whole table into data, which should be either:
+//   - a pointer to a slice of structs []T, filled with one element per row.
+//     Fields are mapped to columns as for Rows.Scan,
+//   - a pointer to a map[string]interface{}, filled with one typed slice per
+//     column ([]float64 for a D column, [][3]float32 for a 3E column, ...)
+//     If the map already holds some keys, only these columns are loaded.
+func (hdu *Table) Data(data interface{}) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cfitsio: Table.Data needs a pointer (got %T)", data)
+	}
+	rv = rv.Elem()
+
+	switch {
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Struct:
+		return hdu.loadStructs(rv)
+	case rv.Type() == reflect.TypeOf(map[string]interface{}{}):
+		return hdu.loadMap(rv)
+	}
+	return fmt.Errorf("cfitsio: Table.Data can not load data into %T", data)
+}
+
+// loadStructs loads the whole table into rv, a slice of structs.
+func (hdu *Table) loadStructs(rv reflect.Value) error {
+	fields, err := typedFields(hdu, rv.Type().Elem())
+	if err != nil {
+		return err
 	}
-	return err
+	nrows := int(hdu.NumRows())
+	slice := reflect.MakeSlice(rv.Type(), nrows, nrows)
+	err = hdu.readStructs(fields, 0, hdu.NumRows(), slice)
+	if err != nil {
+		return err
+	}
+	rv.Set(slice)
+	return nil
 }
 
-func (hdu *Table) load() error {
+// loadMap loads the whole table into rv, a map of column name to column values.
+func (hdu *Table) loadMap(rv reflect.Value) error {
+	icols := make([]int, 0, len(hdu.cols))
+	switch rv.Len() {
+	case 0:
+		for icol := range hdu.cols {
+			icols = append(icols, icol)
+		}
+	default:
+		for _, k := range rv.MapKeys() {
+			icol := hdu.Index(k.String())
+			if icol < 0 {
+				return fmt.Errorf("cfitsio: no column named [%s]", k.String())
+			}
+			icols = append(icols, icol)
+		}
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+
+	err := hdu.seekHDU()
+	if err != nil {
+		return err
+	}
+
+	for _, icol := range icols {
+		col := &hdu.cols[icol]
+		rt := reflect.TypeOf(col.Value)
+		slice := reflect.New(reflect.SliceOf(rt)).Elem()
+		switch {
+		case isBulkType(rt):
+			err = hdu.readColumn(icol, 0, hdu.nrows, slice)
+			if err != nil {
+				return err
+			}
+		default:
+			nrows := int(hdu.nrows)
+			slice.Set(reflect.MakeSlice(slice.Type(), nrows, nrows))
+			for i := 0; i < nrows; i++ {
+				err = col.read(hdu.f, icol, int64(i), slice.Index(i).Addr().Interface())
+				if err != nil {
+					return err
+				}
+			}
+		}
+		rv.SetMapIndex(reflect.ValueOf(col.Name), slice)
+	}
 	return nil
 }
 
@@ -277,6 +368,31 @@ func (hdu *Table) readRow(irow int64) error {
 // if end > maxrows, the iteration will stop at maxrows
 // ReadRange has the same semantics than a `for i=0; i < max; i+=inc {...}` loop
 func (hdu *Table) ReadRange(beg, end, inc int64) (*Rows, error) {
+	cols := make([]int, len(hdu.cols))
+	for i := range hdu.cols {
+		cols[i] = i
+	}
+	return hdu.readRange(beg, end, inc, cols)
+}
+
+// ReadColumns reads rows over the range [beg, end) and returns the
+// corresponding iterator, restricted to the columns named names.
+// Rows.Scan only reads these columns, in the order of names.
+func (hdu *Table) ReadColumns(beg, end int64, names ...string) (*Rows, error) {
+	cols := make([]int, len(names))
+	for i, n := range names {
+		icol := hdu.Index(n)
+		if icol < 0 {
+			return nil, fmt.Errorf("cfitsio: no column named [%s]", n)
+		}
+		cols[i] = icol
+	}
+	return hdu.readRange(beg, end, 1, cols)
+}
+
+// readRange returns an iterator over the rows [beg, end), by steps of inc,
+// and over the columns cols.
+func (hdu *Table) readRange(beg, end, inc int64, cols []int) (*Rows, error) {
 	var rows *Rows
 	err := hdu.seekHDU()
 	if err != nil {
@@ -292,11 +408,6 @@ func (hdu *Table) ReadRange(beg, end, inc int64) (*Rows, error) {
 		beg = 0
 	}
 
-	cols := make([]int, len(hdu.cols))
-	for i := range hdu.cols {
-		cols[i] = i
-	}
-
 	rows = &Rows{
 		table: hdu,
 		cols:  cols,
@@ -304,6 +415,7 @@ func (hdu *Table) ReadRange(beg, end, inc int64) (*Rows, error) {
 		n:     end,
 		inc:   inc,
 		cur:   beg - inc,
+		gen:   hdu.gen,
 		err:   nil,
 	}
 	return rows, err
@@ -323,7 +435,7 @@ func (hdu *Table) seekHDU() error {
 	if c_status > 0 {
 		return to_err(c_status)
 	}
-	return nil
+	return hdu.applyRawMode()
 }
 
 func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
@@ -352,6 +464,7 @@ func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
 	get := func(str string, ii int) *Card {
 		return hdr.Get(fmt.Sprintf(str+"%d", ii+1))
 	}
+	tref := newTimeRef(&hdr)
 	for ii := 0; ii < ncols; ii++ {
 		col := &cols[ii]
 		// column name
@@ -372,46 +485,38 @@ func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
 		card := get("TFORM", ii)
 		if card != nil {
 			col.Format = card.Value.(string)
+			col.parseVLA()
 		}
 
 		card = get("TUNIT", ii)
 		if card != nil {
 			col.Unit = card.Value.(string)
 		}
+		col.tref = tref.column(col.Unit)
 
 		card = get("TNULL", ii)
 		if card != nil {
-			col.Null = card.Value.(string)
+			col.Null = card.Value
 		}
 
+		col.Bscale = 1.0
 		card = get("TSCAL", ii)
 		if card != nil {
-			switch vv := card.Value.(type) {
-			case float64:
-				col.Bscale = vv
-			case int64:
-				col.Bscale = float64(vv)
-			default:
-				panic(fmt.Errorf("unhandled type [%T]", vv))
+			v, ok := cardFloat(card.Value)
+			if !ok {
+				return nil, fmt.Errorf("cfitsio: invalid %s value [%v] (%T)", card.Name, card.Value, card.Value)
 			}
-			//col.Bscale = card.Value.(float64)
-		} else {
-			col.Bscale = 1.0
+			col.Bscale = v
 		}
 
+		col.Bzero = 0.0
 		card = get("TZERO", ii)
 		if card != nil {
-			switch vv := card.Value.(type) {
-			case float64:
-				col.Bzero = vv
-			case int64:
-				col.Bzero = float64(vv)
-			default:
-				panic(fmt.Errorf("unhandled type [%T]", vv))
+			v, ok := cardFloat(card.Value)
+			if !ok {
+				return nil, fmt.Errorf("cfitsio: invalid %s value [%v] (%T)", card.Name, card.Value, card.Value)
 			}
-			//col.Bzero = card.Value.(float64)
-		} else {
-			col.Bzero = 0.0
+			col.Bzero = v
 		}
 
 		card = get("TDISP", ii)
@@ -427,24 +532,9 @@ func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
 		}
 		card = get("TDIM", ii)
 		if card != nil {
-			dims := card.Value.(string)
-			dims = strings.Replace(dims, "(", "", -1)
-			dims = strings.Replace(dims, ")", "", -1)
-			toks := make([]string, 0)
-			for _, tok := range strings.Split(dims, ",") {
-				tok = strings.Trim(tok, " \t\n")
-				if tok == "" {
-					continue
-				}
-				toks = append(toks, tok)
-			}
-			col.Dim = make([]int64, 0, len(toks))
-			for _, tok := range toks {
-				dim, err := strconv.ParseInt(tok, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				col.Dim = append(col.Dim, dim)
+			col.Dim, err = parseDims(card.Value.(string))
+			if err != nil {
+				return nil, err
 			}
 		}
 
@@ -453,17 +543,9 @@ func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
 			col.Start = card.Value.(int64)
 		}
 
-		{
-			c_type := C.int(0)
-			c_repeat := C.long(0)
-			c_width := C.long(0)
-			c_status := C.int(0)
-			c_col := C.int(ii + 1) // 1-based index
-			C.fits_get_coltype(f.c, c_col, &c_type, &c_repeat, &c_width, &c_status)
-			if c_status > 0 {
-				return nil, to_err(c_status)
-			}
-			col.Value = govalue_from_typecode(TypeCode(c_type))
+		err = col.resetValue(f, ii)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -473,7 +555,6 @@ func newTable(f *File, hdr Header, i int) (hdu HDU, err error) {
 		header: hdr,
 		nrows:  int64(c_nrows),
 		cols:   cols,
-		data:   nil,
 	}
 	return hdu, err
 }
@@ -518,6 +599,10 @@ func NewTable(f *File, name string, cols []Column, hdutype HDUType) (*Table, err
 		if err != nil {
 			return table, err
 		}
+		err = col.checkNull(hdutype)
+		if err != nil {
+			return table, err
+		}
 		c_form := C.CString(col.Format)
 		defer C.free(unsafe.Pointer(c_form))
 		C.char_array_set(c_forms, c_idx, c_form)
@@ -527,7 +612,28 @@ func NewTable(f *File, name string, cols []Column, hdutype HDUType) (*Table, err
 		C.char_array_set(c_units, c_idx, c_unit)
 	}
 
-	C.fits_create_tbl(f.c, C.int(hdutype), 0, C.int(len(cols)), c_types, c_forms, c_units, c_hduname, &c_status)
+	if hdutype == ASCII_TBL {
+		err = createASCIITable(f, cols, c_types, c_forms, c_units, c_hduname)
+		if err != nil {
+			return table, err
+		}
+		nhdus = len(f.hdus)
+	} else {
+		C.fits_create_tbl(f.c, C.int(hdutype), 0, C.int(len(cols)), c_types, c_forms, c_units, c_hduname, &c_status)
+		if c_status > 0 {
+			return table, to_err(c_status)
+		}
+	}
+
+	for i := range cols {
+		err = cols[i].writeKeys(f, i, hdutype)
+		if err != nil {
+			return table, err
+		}
+	}
+
+	// make CFITSIO aware of the new column keywords (TNULL, ...)
+	C.fits_set_hdustruc(f.c, &c_status)
 	if c_status > 0 {
 		return table, to_err(c_status)
 	}
@@ -573,7 +679,9 @@ func (hdu *Table) Write(args ...interface{}) error {
 		case reflect.Map:
 			return hdu.writeMap(irow, *args[0].(*map[string]interface{}))
 		case reflect.Struct:
-			return hdu.writeStruct(irow, args[0])
+			if !isCellType(rt) {
+				return hdu.writeStruct(irow, args[0])
+			}
 		}
 	}
 
@@ -603,17 +711,9 @@ func (hdu *Table) writeStruct(irow int64, data interface{}) error {
 	var err error
 	rt := reflect.TypeOf(data).Elem()
 	rv := reflect.ValueOf(data).Elem()
-	icols := make([][2]int, 0, rt.NumField())
-	for i := 0; i < rt.NumField(); i++ {
-		f := rt.Field(i)
-		n := f.Tag.Get("fits")
-		if n == "" {
-			n = f.Name
-		}
-		icol := hdu.Index(n)
-		if icol >= 0 {
-			icols = append(icols, [2]int{i, icol})
-		}
+	icols, err := hdu.structColumns(rt)
+	if err != nil {
+		return err
 	}
 
 	for _, icol := range icols {
@@ -654,60 +754,6 @@ func CopyTable(dst, src *Table) error {
 	return CopyTableRange(dst, src, 0, src.NumRows())
 }
 
-// CopyTableRange copies the rows interval [beg,end) from src into dst
-func CopyTableRange(dst, src *Table, beg, end int64) error {
-	var err error
-	if dst == nil {
-		return fmt.Errorf("cfitsio: dst pointer is nil")
-	}
-	if src == nil {
-		return fmt.Errorf("cfitsio: src pointer is nil")
-	}
-
-	defer func() {
-		// update nrows
-		c_nrows := C.long(0)
-		c_status := C.int(0)
-		C.fits_get_num_rows(dst.f.c, &c_nrows, &c_status)
-		if c_status > 0 {
-			return
-		}
-		dst.nrows = int64(c_nrows)
-	}()
-
-	err = dst.seekHDU()
-	if err != nil {
-		return err
-	}
-	err = src.seekHDU()
-	if err != nil {
-		return err
-	}
-
-	hdr := src.Header()
-	buf := make([]byte, int(hdr.Axes()[0]))
-	slice := (*reflect.SliceHeader)((unsafe.Pointer(&buf)))
-	c_ptr := (*C.uchar)(unsafe.Pointer(slice.Data))
-	c_len := C.LONGLONG(len(buf))
-	c_orow := C.LONGLONG(dst.nrows)
-	for irow := beg; irow < end; irow++ {
-		c_status := C.int(0)
-		c_row := C.LONGLONG(irow) + 1 // from 0-based to 1-based index
-		C.fits_read_tblbytes(src.f.c, c_row, 1, c_len, c_ptr, &c_status)
-		if c_status > 0 {
-			return to_err(c_status)
-		}
-
-		fmt.Printf(">>> [%d] %v\n", irow, buf)
-		C.fits_write_tblbytes(dst.f.c, c_orow+c_row, 1, c_len, c_ptr, &c_status)
-		if c_status > 0 {
-			return to_err(c_status)
-		}
-	}
-
-	return err
-}
-
 func init() {
 	g_hdus[ASCII_TBL] = newTable
 	g_hdus[BINARY_TBL] = newTable
@@ -3,9 +3,11 @@ package cfitsio
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestTable(t *testing.T) {
@@ -957,24 +959,39 @@ func TestTableBuiltinsRW(t *testing.T) {
 				"18", "19", "10", "11",
 			},
 		},
-		// {
-		// 	name: "new.fits",
-		// 	cols: []Column{
-		// 		{
-		// 			Name:  "float64s",
-		// 			Value: [2]float64{},
-		// 		},
-		// 	},
-		// 	htype: BINARY_TBL,
-		// 	table: [][2]float64{
-		// 		{10, 11},
-		// 		{12, 13},
-		// 		{14, 15},
-		// 		{16, 17},
-		// 		{18, 19},
-		// 		{10, 11},
-		// 	},
-		// },
+		{
+			name: "new.fits",
+			cols: []Column{
+				{
+					Name:  "float64s",
+					Value: [2]float64{},
+				},
+			},
+			htype: BINARY_TBL,
+			table: [][2]float64{
+				{10, 11},
+				{12, 13},
+				{14, 15},
+				{16, 17},
+				{18, 19},
+				{10, 11},
+			},
+		},
+		{
+			name: "new.fits",
+			cols: []Column{
+				{
+					Name:  "int32s",
+					Value: [2][3]int32{},
+				},
+			},
+			htype: BINARY_TBL,
+			table: [][2][3]int32{
+				{{10, 11, 12}, {13, 14, 15}},
+				{{16, 17, 18}, {19, 10, 11}},
+				{{12, 13, 14}, {15, 16, 17}},
+			},
+		},
 	} {
 		fname := fmt.Sprintf("%03d_%s", ii, table.name)
 		for _, fct := range []func(){
@@ -1690,4 +1707,2422 @@ func TestTableMapsRW(t *testing.T) {
 	}
 }
 
+func TestTableTDIM(t *testing.T) {
+	type Data struct {
+		ID     int64         `fits:"id"`
+		Matrix [2][3]float32 `fits:"matrix"`
+		Slices [][]int16     `fits:"slices"`
+	}
+
+	data := []Data{
+		{0, [2][3]float32{{0, 1, 2}, {3, 4, 5}}, [][]int16{{0, 1}, {2, 3}, {4, 5}}},
+		{1, [2][3]float32{{6, 7, 8}, {9, 10, 11}}, [][]int16{{6, 7}, {8, 9}, {10, 11}}},
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	for _, fct := range []func(){
+		// create
+		func() {
+			cols := []Column{
+				{Name: "id", Value: int64(0)},
+				{Name: "matrix", Value: [2][3]float32{}},
+				{Name: "slices", Value: [][]int16{}, Dim: []int64{2, 3}},
+			}
+			tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+			if err != nil {
+				t.Fatalf("error creating new table: %v", err)
+			}
+			defer tbl.Close()
+
+			for i := range data {
+				err = tbl.Write(&data[i])
+				if err != nil {
+					t.Fatalf("error writing row [%v]: %v", i, err)
+				}
+			}
+		},
+		// read
+		func() {
+			err := f.Close()
+			if err != nil {
+				t.Fatalf("error closing file: %v", err)
+			}
+			*f, err = Open("new.fits", ReadOnly)
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+
+			tbl := f.HDU(1).(*Table)
+			for _, table := range []struct {
+				name   string
+				format string
+				dim    []int64
+				value  interface{}
+			}{
+				{"matrix", "6E", []int64{3, 2}, [2][3]float32{}},
+				{"slices", "6I", []int64{2, 3}, [3][2]int16{}},
+			} {
+				col := tbl.Col(tbl.Index(table.name))
+				if col.Format != table.format {
+					t.Fatalf("%s: expected TFORM=%q. got %q", table.name, table.format, col.Format)
+				}
+				if !reflect.DeepEqual(col.Dim, table.dim) {
+					t.Fatalf("%s: expected TDIM=%v. got %v", table.name, table.dim, col.Dim)
+				}
+				if reflect.TypeOf(col.Value) != reflect.TypeOf(table.value) {
+					t.Fatalf("%s: expected a %T value. got %T", table.name, table.value, col.Value)
+				}
+			}
+
+			rows, err := tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			defer rows.Close()
+			irow := 0
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[irow]) {
+					t.Fatalf("row #%d:\nexpected=%v\ngot=%v", irow, data[irow], row)
+				}
+				irow++
+			}
+			if irow != len(data) {
+				t.Fatalf("expected [%v] rows. got [%v]", len(data), irow)
+			}
+
+			var matrices [][2][3]float32
+			err = tbl.ReadColumn("matrix", 0, tbl.NumRows(), &matrices)
+			if err != nil {
+				t.Fatalf("error reading column: %v", err)
+			}
+			for i := range data {
+				if matrices[i] != data[i].Matrix {
+					t.Fatalf("row #%d:\nexpected=%v\ngot=%v", i, data[i].Matrix, matrices[i])
+				}
+			}
+		},
+	} {
+		fct()
+	}
+}
+
+func TestTableNull(t *testing.T) {
+	type Data struct {
+		I32 *int32      `fits:"i32"`
+		I64 NullInt64   `fits:"i64"`
+		F64 NullFloat64 `fits:"f64"`
+		B   NullBool    `fits:"b"`
+	}
+
+	i32 := int32(42)
+	data := []Data{
+		{&i32, NullInt64{-5, true}, NullFloat64{1.5, true}, NullBool{false, true}},
+		{nil, NullInt64{}, NullFloat64{}, NullBool{}},
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	for _, fct := range []func(){
+		// create
+		func() {
+			_, err := NewTable(f, "bad", []Column{{Name: "f64", Format: "D", Null: int64(-1)}}, BINARY_TBL)
+			if err == nil {
+				t.Fatalf("expected an error creating a floating-point column with TNULL")
+			}
+
+			cols := []Column{
+				{Name: "i32", Format: "J", Null: int64(-99)},
+				{Name: "i64", Format: "K", Null: int64(-1)},
+				{Name: "f64", Format: "D"},
+				{Name: "b", Format: "L"},
+			}
+			tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+			if err != nil {
+				t.Fatalf("error creating new table: %v", err)
+			}
+			defer tbl.Close()
+
+			for i := range data {
+				err = tbl.Write(&data[i])
+				if err != nil {
+					t.Fatalf("error writing row [%v]: %v", i, err)
+				}
+			}
+		},
+		// read
+		func() {
+			err := f.Close()
+			if err != nil {
+				t.Fatalf("error closing file: %v", err)
+			}
+			*f, err = Open("new.fits", ReadOnly)
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+
+			tbl := f.HDU(1).(*Table)
+			if null := tbl.Col(0).Null; null != int64(-99) {
+				t.Fatalf("expected TNULL1=-99. got %v (%T)", null, null)
+			}
+
+			rows, err := tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			defer rows.Close()
+			irow := 0
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[irow]) {
+					t.Fatalf("row #%d:\nexpected=%+v\ngot=%+v", irow, data[irow], row)
+				}
+
+				var (
+					raw int32
+					ptr *int64
+					f64 *float64
+					b   bool
+				)
+				err = rows.Scan(&raw, &ptr, &f64, &b)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				switch irow {
+				case 0:
+					if raw != 42 || ptr == nil || *ptr != -5 || f64 == nil || *f64 != 1.5 {
+						t.Fatalf("row #%d: invalid values (%v, %v, %v)", irow, raw, ptr, f64)
+					}
+				case 1:
+					if raw != -99 || ptr != nil || f64 != nil {
+						t.Fatalf("row #%d: expected undefined values. got (%v, %v, %v)", irow, raw, ptr, f64)
+					}
+				}
+				irow++
+			}
+			if irow != len(data) {
+				t.Fatalf("expected [%v] rows. got [%v]", len(data), irow)
+			}
+		},
+	} {
+		fct()
+	}
+}
+
+func TestTableScaling(t *testing.T) {
+	type Data struct {
+		S   float32 `fits:"s"`
+		U16 uint16  `fits:"u16"`
+		U32 uint32  `fits:"u32"`
+		U64 uint64  `fits:"u64"`
+	}
+
+	data := []Data{
+		{10, 0, 0, 0},
+		{10.5, 1, 2, 3},
+		{11.5, math.MaxUint16, math.MaxUint32, math.MaxUint64},
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	for _, fct := range []func(){
+		// create
+		func() {
+			cols := []Column{
+				{Name: "s", Format: "I", Bscale: 0.5, Bzero: 10},
+				{Name: "u16", Value: uint16(0)},
+				{Name: "u32", Value: uint32(0)},
+				{Name: "u64", Value: uint64(0)},
+			}
+			tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+			if err != nil {
+				t.Fatalf("error creating new table: %v", err)
+			}
+			defer tbl.Close()
+
+			for i := range data {
+				err = tbl.Write(&data[i])
+				if err != nil {
+					t.Fatalf("error writing row [%v]: %v", i, err)
+				}
+			}
+		},
+		// read
+		func() {
+			err := f.Close()
+			if err != nil {
+				t.Fatalf("error closing file: %v", err)
+			}
+			*f, err = Open("new.fits", ReadOnly)
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+
+			tbl := f.HDU(1).(*Table)
+			for i, want := range []struct {
+				bscale float64
+				bzero  float64
+				value  Value
+			}{
+				{0.5, 10, float32(0)},
+				{1, 1 << 15, uint16(0)},
+				{1, 1 << 31, uint32(0)},
+				{1, 1 << 63, uint64(0)},
+			} {
+				col := tbl.Col(i)
+				if col.Bscale != want.bscale || col.Bzero != want.bzero {
+					t.Fatalf("col[%s]: expected (TSCAL,TZERO)=(%v,%v). got (%v,%v)",
+						col.Name, want.bscale, want.bzero, col.Bscale, col.Bzero,
+					)
+				}
+				if !reflect.DeepEqual(col.Value, want.value) {
+					t.Fatalf("col[%s]: expected value type %T. got %T", col.Name, want.value, col.Value)
+				}
+			}
+
+			// scaled values
+			rows, err := tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			irow := 0
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[irow]) {
+					t.Fatalf("row #%d:\nexpected=%+v\ngot=%+v", irow, data[irow], row)
+				}
+				irow++
+			}
+			if irow != len(data) {
+				t.Fatalf("expected [%v] rows. got [%v]", len(data), irow)
+			}
+
+			// raw values
+			err = tbl.SetRawMode(true, "s", "u16")
+			if err != nil {
+				t.Fatalf("table.SetRawMode: %v", err)
+			}
+			if _, ok := tbl.Col(0).Value.(int16); !ok {
+				t.Fatalf("expected a raw int16 value. got %T", tbl.Col(0).Value)
+			}
+			rows, err = tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			irow = 0
+			for rows.Next() {
+				var (
+					s   int16
+					u16 int16
+					u32 uint32
+					u64 uint64
+				)
+				err = rows.Scan(&s, &u16, &u32, &u64)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				want := data[irow]
+				if s != int16((want.S-10)*2) || u16 != int16(int32(want.U16)-1<<15) {
+					t.Fatalf("row #%d: invalid raw values (%v, %v)", irow, s, u16)
+				}
+				if u32 != want.U32 || u64 != want.U64 {
+					t.Fatalf("row #%d: invalid scaled values (%v, %v)", irow, u32, u64)
+				}
+				irow++
+			}
+
+			err = tbl.SetRawMode(false)
+			if err != nil {
+				t.Fatalf("table.SetRawMode: %v", err)
+			}
+			rows, err = tbl.Read(2, 3)
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[2]) {
+					t.Fatalf("expected=%+v\ngot=%+v", data[2], row)
+				}
+			}
+
+			err = tbl.SetRawMode(true, "NONE")
+			if err == nil {
+				t.Fatalf("expected an error for a missing column")
+			}
+		},
+	} {
+		fct()
+	}
+}
+
+func TestBitArray(t *testing.T) {
+	bits := NewBitArray(10)
+	if bits.Len() != 10 {
+		t.Fatalf("expected 10 bits. got %d", bits.Len())
+	}
+	bits.Set(0)
+	bits.Set(3)
+	bits.Set(9)
+	bits.Set(8)
+	bits.Clear(8)
+	if str := bits.String(); str != "1001000001" {
+		t.Fatalf("expected bits [1001000001]. got [%s]", str)
+	}
+	if !bits.Test(9) || bits.Test(8) {
+		t.Fatalf("invalid bits: %v", bits)
+	}
+}
+
+func TestTableBits(t *testing.T) {
+	type Data struct {
+		Flags BitArray `fits:"flags"`
+		Mask  BitArray `fits:"mask"`
+	}
+
+	nrows := 5
+	data := make([]Data, nrows)
+	for i := range data {
+		data[i] = Data{NewBitArray(12), NewBitArray(3)}
+		data[i].Flags.Set(i)
+		data[i].Flags.Set(11 - i)
+		data[i].Mask.Set(i % 3)
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	for _, fct := range []func(){
+		// create
+		func() {
+			cols := []Column{
+				{Name: "flags", Value: NewBitArray(12)},
+				{Name: "mask", Format: "3L"},
+			}
+			tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+			if err != nil {
+				t.Fatalf("error creating new table: %v", err)
+			}
+			defer tbl.Close()
+
+			for i := range data {
+				err = tbl.Write(&data[i])
+				if err != nil {
+					t.Fatalf("error writing row [%v]: %v", i, err)
+				}
+			}
+
+			bits := NewBitArray(4)
+			err = tbl.Write(&bits)
+			if err == nil {
+				t.Fatalf("expected an error writing 4 bits into a 12X column")
+			}
+		},
+		// read
+		func() {
+			err := f.Close()
+			if err != nil {
+				t.Fatalf("error closing file: %v", err)
+			}
+			*f, err = Open("new.fits", ReadOnly)
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+
+			tbl := f.HDU(1).(*Table)
+			if format := tbl.Col(0).Format; format != "12X" {
+				t.Fatalf("expected TFORM1=12X. got %q", format)
+			}
+			if bits, ok := tbl.Col(0).Value.(BitArray); !ok || bits.Len() != 12 {
+				t.Fatalf("expected a 12-bits BitArray value. got %v (%T)", tbl.Col(0).Value, tbl.Col(0).Value)
+			}
+
+			rows, err := tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			defer rows.Close()
+			irow := 0
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[irow]) {
+					t.Fatalf("row #%d:\nexpected=%v\ngot=%v", irow, data[irow], row)
+				}
+				irow++
+			}
+			if irow != nrows {
+				t.Fatalf("expected [%v] rows. got [%v]", nrows, irow)
+			}
+		},
+	} {
+		fct()
+	}
+}
+
+func TestNewTableFromStruct(t *testing.T) {
+	type Data struct {
+		ID   int64       `fits:"id"`
+		Flux float32     `fits:"flux,unit=Jy,format=1J,scale=0.5,null=-999"`
+		Mag  *int32      `fits:"mag,null=-1"`
+		Pix  [2][3]int16 `fits:"pix,disp=I4"`
+		Name string      `fits:"name,format=8A"`
+		Tmp  float64     `fits:"-"`
+		priv int
+	}
+
+	mag := int32(3)
+	data := []Data{
+		{ID: 1, Flux: 1.5, Mag: &mag, Pix: [2][3]int16{{1, 2, 3}, {4, 5, 6}}, Name: "a"},
+		{ID: 2, Flux: -2, Mag: nil, Pix: [2][3]int16{{-1, -2, -3}, {-4, -5, -6}}, Name: "bbb"},
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	for _, fct := range []func(){
+		// create
+		func() {
+			_, err := NewTableFromStruct(f, "bad", struct {
+				X float64 `fits:"x,bogus=1"`
+			}{}, BINARY_TBL)
+			if err == nil {
+				t.Fatalf("expected an error for an invalid struct tag")
+			}
+
+			tbl, err := NewTableFromStruct(f, "test", &Data{}, BINARY_TBL)
+			if err != nil {
+				t.Fatalf("error creating new table: %v", err)
+			}
+			defer tbl.Close()
+
+			for i := range data {
+				err = tbl.Write(&data[i])
+				if err != nil {
+					t.Fatalf("error writing row [%v]: %v", i, err)
+				}
+			}
+		},
+		// read
+		func() {
+			err := f.Close()
+			if err != nil {
+				t.Fatalf("error closing file: %v", err)
+			}
+			*f, err = Open("new.fits", ReadOnly)
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+
+			tbl := f.HDU(1).(*Table)
+			if tbl.NumCols() != 5 {
+				t.Fatalf("expected 5 columns. got %d", tbl.NumCols())
+			}
+			for i, name := range []string{"id", "flux", "mag", "pix", "name"} {
+				if n := tbl.Col(i).Name; n != name {
+					t.Fatalf("col[%d]: expected name %q. got %q", i, name, n)
+				}
+			}
+
+			flux := tbl.Col(1)
+			if flux.Format != "1J" || flux.Unit != "Jy" || flux.Bscale != 0.5 || flux.Null != int64(-999) {
+				t.Fatalf("invalid flux column: %+v", *flux)
+			}
+			if null := tbl.Col(2).Null; null != int64(-1) {
+				t.Fatalf("expected TNULL3=-1. got %v", null)
+			}
+			pix := tbl.Col(3)
+			if pix.Display != "I4" || !reflect.DeepEqual(pix.Dim, []int64{3, 2}) {
+				t.Fatalf("invalid pix column: %+v", *pix)
+			}
+
+			rows, err := tbl.Read(0, tbl.NumRows())
+			if err != nil {
+				t.Fatalf("table.Read: %v", err)
+			}
+			defer rows.Close()
+			irow := 0
+			for rows.Next() {
+				var row Data
+				err = rows.Scan(&row)
+				if err != nil {
+					t.Fatalf("rows.Scan: %v", err)
+				}
+				if !reflect.DeepEqual(row, data[irow]) {
+					t.Fatalf("row #%d:\nexpected=%+v\ngot=%+v", irow, data[irow], row)
+				}
+				irow++
+			}
+			if irow != len(data) {
+				t.Fatalf("expected [%v] rows. got [%v]", len(data), irow)
+			}
+		},
+	} {
+		fct()
+	}
+}
+
+func TestTableData(t *testing.T) {
+	type Data struct {
+		ID int64      `fits:"id"`
+		X  float64    `fits:"x"`
+		V  [2]float32 `fits:"v"`
+		S  string     `fits:"s,format=4A"`
+	}
+
+	data := []Data{
+		{1, 1.5, [2]float32{1, 2}, "a"},
+		{2, 2.5, [2]float32{3, 4}, "bb"},
+		{3, 3.5, [2]float32{5, 6}, "ccc"},
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	tbl, err := NewTableFromStruct(f, "test", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	var rows []Data
+	err = tbl.Data(&rows)
+	if err != nil {
+		t.Fatalf("error loading table: %v", err)
+	}
+	if !reflect.DeepEqual(rows, data) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data, rows)
+	}
+
+	cols := make(map[string]interface{})
+	err = tbl.Data(&cols)
+	if err != nil {
+		t.Fatalf("error loading table: %v", err)
+	}
+	want := map[string]interface{}{
+		"id": []int64{1, 2, 3},
+		"x":  []float64{1.5, 2.5, 3.5},
+		"v":  [][2]float32{{1, 2}, {3, 4}, {5, 6}},
+		"s":  []string{"a", "bb", "ccc"},
+	}
+	if !reflect.DeepEqual(cols, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, cols)
+	}
+
+	cols = map[string]interface{}{"x": nil}
+	err = tbl.Data(&cols)
+	if err != nil {
+		t.Fatalf("error loading table: %v", err)
+	}
+	if !reflect.DeepEqual(cols, map[string]interface{}{"x": want["x"]}) {
+		t.Fatalf("expected only column [x]. got %v", cols)
+	}
+
+	var ints []int
+	err = tbl.Data(&ints)
+	if err == nil {
+		t.Fatalf("expected an error loading a table into %T", ints)
+	}
+}
+
+func TestTableEditRows(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	tbl, err := NewTable(f, "test", []Column{{Name: "n", Format: "K"}}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	err = tbl.WriteColumn("n", 0, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if err != nil {
+		t.Fatalf("error writing column: %v", err)
+	}
+
+	check := func(want []int64) {
+		var ns []int64
+		err := tbl.ReadColumn("n", 0, tbl.NumRows(), &ns)
+		if err != nil {
+			t.Fatalf("error reading column: %v", err)
+		}
+		if tbl.NumRows() != int64(len(want)) || !reflect.DeepEqual(ns, want) {
+			t.Fatalf("expected\nref=%v\ngot=%v (nrows=%d)", want, ns, tbl.NumRows())
+		}
+	}
+
+	err = tbl.InsertRows(2, 2)
+	if err != nil {
+		t.Fatalf("error inserting rows: %v", err)
+	}
+	check([]int64{0, 1, 0, 0, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	err = tbl.DeleteRows(2, 2)
+	if err != nil {
+		t.Fatalf("error deleting rows: %v", err)
+	}
+	check([]int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	err = tbl.DeleteRowList([]int64{5, 1, 3, 3})
+	if err != nil {
+		t.Fatalf("error deleting rows: %v", err)
+	}
+	check([]int64{0, 2, 4, 6, 7, 8, 9})
+
+	n, err := tbl.DeleteRowsWhere("N > 6")
+	if err != nil {
+		t.Fatalf("error deleting rows: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 deleted rows. got %d", n)
+	}
+	check([]int64{0, 2, 4, 6})
+
+	for _, err := range []error{
+		tbl.InsertRows(5, 1),
+		tbl.DeleteRows(3, 2),
+		tbl.DeleteRowList([]int64{4}),
+	} {
+		if err == nil {
+			t.Fatalf("expected an error for an out of range row")
+		}
+	}
+
+	// open iterators are invalidated
+	rows, err := tbl.Read(0, tbl.NumRows())
+	if err != nil {
+		t.Fatalf("table.Read: %v", err)
+	}
+	if !rows.Next() {
+		t.Fatalf("expected a row")
+	}
+	err = tbl.InsertRows(0, 1)
+	if err != nil {
+		t.Fatalf("error inserting rows: %v", err)
+	}
+	var v int64
+	err = rows.Scan(&v)
+	if err == nil {
+		t.Fatalf("expected an error scanning an invalidated iterator")
+	}
+	if rows.Next() {
+		t.Fatalf("expected an invalidated iterator")
+	}
+	if rows.Err() == nil {
+		t.Fatalf("expected an error from an invalidated iterator")
+	}
+}
+
+func TestTableEditColumns(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cols := []Column{
+		{Name: "x", Format: "D"},
+		{Name: "n", Format: "J"},
+		{Name: "v", Format: "3E"},
+	}
+	tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for _, tc := range []struct {
+		name string
+		data interface{}
+	}{
+		{"x", []float64{1, 2, 3}},
+		{"n", []int32{10, 20, 30}},
+		{"v", [][]float32{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}},
+	} {
+		err = tbl.WriteColumn(tc.name, 0, tc.data)
+		if err != nil {
+			t.Fatalf("error writing column [%s]: %v", tc.name, err)
+		}
+	}
+
+	names := func() []string {
+		names := make([]string, 0, tbl.NumCols())
+		for _, col := range tbl.Cols() {
+			names = append(names, col.Name)
+		}
+		return names
+	}
+
+	err = tbl.AddColumn(Column{Name: "y", Unit: "m", Value: float32(0)}, 1)
+	if err != nil {
+		t.Fatalf("error adding column: %v", err)
+	}
+	if want := []string{"x", "y", "n", "v"}; !reflect.DeepEqual(names(), want) {
+		t.Fatalf("expected columns %v. got %v", want, names())
+	}
+	if col := tbl.Col(1); col.Format != "E" || col.Unit != "m" {
+		t.Fatalf("invalid column [y]: %+v", *col)
+	}
+	err = tbl.WriteColumn("y", 0, []float32{-1, -2, -3})
+	if err != nil {
+		t.Fatalf("error writing column [y]: %v", err)
+	}
+
+	err = tbl.DeleteColumn("x")
+	if err != nil {
+		t.Fatalf("error deleting column: %v", err)
+	}
+	err = tbl.RenameColumn("n", "id")
+	if err != nil {
+		t.Fatalf("error renaming column: %v", err)
+	}
+	if want := []string{"y", "id", "v"}; !reflect.DeepEqual(names(), want) {
+		t.Fatalf("expected columns %v. got %v", want, names())
+	}
+	hdr := tbl.Header()
+	if card := hdr.Get("TTYPE2"); card == nil || card.Value != "id" {
+		t.Fatalf("expected TTYPE2=id. got %v", card)
+	}
+
+	err = tbl.ModifyColumn("v", "4E")
+	if err != nil {
+		t.Fatalf("error modifying column [v]: %v", err)
+	}
+	err = tbl.ModifyColumn("id", "D")
+	if err != nil {
+		t.Fatalf("error modifying column [id]: %v", err)
+	}
+
+	err = tbl.AddColumn(Column{Name: "m", Format: "6E", Dim: []int64{2, 3}}, -1)
+	if err != nil {
+		t.Fatalf("error adding column [m]: %v", err)
+	}
+	err = tbl.ModifyColumn("m", "8E")
+	if err != nil {
+		t.Fatalf("error modifying column [m]: %v", err)
+	}
+	if dim := tbl.Col(3).Dim; !reflect.DeepEqual(dim, []int64{2, 4}) {
+		t.Fatalf("expected TDIM4=(2,4). got %v", dim)
+	}
+	err = tbl.ModifyColumn("m", "5E")
+	if err != nil {
+		t.Fatalf("error modifying column [m]: %v", err)
+	}
+	hdr = tbl.Header()
+	if card := hdr.Get("TDIM4"); card != nil || len(tbl.Col(3).Dim) != 0 {
+		t.Fatalf("expected no TDIM4. got %v", card)
+	}
+
+	var (
+		ys  []float32
+		ids []float64
+		vs  [][]float32
+	)
+	for _, tc := range []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{"y", &ys, []float32{-1, -2, -3}},
+		{"id", &ids, []float64{10, 20, 30}},
+		{"v", &vs, [][]float32{{1, 2, 3, 0}, {4, 5, 6, 0}, {7, 8, 9, 0}}},
+	} {
+		err = tbl.ReadColumn(tc.name, 0, tbl.NumRows(), tc.data)
+		if err != nil {
+			t.Fatalf("error reading column [%s]: %v", tc.name, err)
+		}
+		got := reflect.ValueOf(tc.data).Elem().Interface()
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("column [%s]: expected\nref=%v\ngot=%v", tc.name, tc.want, got)
+		}
+	}
+
+	for _, err := range []error{
+		tbl.AddColumn(Column{Name: "y", Format: "E"}, 0),
+		tbl.DeleteColumn("x"),
+		tbl.RenameColumn("y", "id"),
+		tbl.ModifyColumn("none", "E"),
+	} {
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+	}
+}
+
+func TestTableWhere(t *testing.T) {
+	cols := func() []Column {
+		return []Column{
+			{Name: "n", Format: "K"},
+			{Name: "x", Format: "D"},
+		}
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	tbl, err := NewTable(f, "test", cols(), BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := 0; i < 10; i++ {
+		n := int64(i)
+		x := float64(i) * 0.5
+		err = tbl.Write(&n, &x)
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	rows, err := tbl.Where("N > 6")
+	if err != nil {
+		t.Fatalf("table.Where: %v", err)
+	}
+	ns := []int64{}
+	for rows.Next() {
+		var (
+			n int64
+			x float64
+		)
+		err = rows.Scan(&n, &x)
+		if err != nil {
+			t.Fatalf("rows.Scan: %v", err)
+		}
+		if x != float64(n)*0.5 {
+			t.Fatalf("row [%d]: invalid x value (%v)", n, x)
+		}
+		ns = append(ns, n)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("rows.Err: %v", err)
+	}
+	if want := []int64{7, 8, 9}; !reflect.DeepEqual(ns, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, ns)
+	}
+
+	n, err := tbl.Count("N % 2 == 0")
+	if err != nil {
+		t.Fatalf("table.Count: %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 rows. got %d", n)
+	}
+
+	_, err = tbl.Count("NONE > 2")
+	if err == nil {
+		t.Fatalf("expected an error for an invalid expression")
+	}
+
+	// select into a table of the same file
+	same, err := NewTable(f, "same", cols(), BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer same.Close()
+
+	// select into a table of another file
+	g, gdone := newTestFile(t)
+	defer gdone()
+	other, err := NewTable(g, "other", cols(), BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer other.Close()
+
+	for _, dst := range []*Table{same, other} {
+		err = tbl.Select(dst, "N < 3 || X > 4")
+		if err != nil {
+			t.Fatalf("table.Select: %v", err)
+		}
+		var got []int64
+		err = dst.ReadColumn("n", 0, dst.NumRows(), &got)
+		if err != nil {
+			t.Fatalf("error reading column: %v", err)
+		}
+		if want := []int64{0, 1, 2, 9}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("table [%s]: expected\nref=%v\ngot=%v", dst.Name(), want, got)
+		}
+	}
+}
+
+func TestTableEval(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cols := []Column{
+		{Name: "COUNTS", Format: "J"},
+		{Name: "EXPTIME", Format: "D"},
+	}
+	tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	err = tbl.WriteColumn("COUNTS", 0, []int32{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("error writing column: %v", err)
+	}
+	err = tbl.WriteColumn("EXPTIME", 0, []float64{2, 2, 4, 4})
+	if err != nil {
+		t.Fatalf("error writing column: %v", err)
+	}
+
+	var flux []float64
+	err = tbl.Eval("COUNTS / EXPTIME", &flux)
+	if err != nil {
+		t.Fatalf("table.Eval: %v", err)
+	}
+	if want := []float64{0.5, 1, 0.75, 1}; !reflect.DeepEqual(flux, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, flux)
+	}
+
+	var mask []bool
+	err = tbl.Eval("COUNTS > 2", &mask)
+	if err != nil {
+		t.Fatalf("table.Eval: %v", err)
+	}
+	if want := []bool{false, false, true, true}; !reflect.DeepEqual(mask, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, mask)
+	}
+
+	var strs []string
+	err = tbl.Eval("COUNTS", &strs)
+	if err == nil {
+		t.Fatalf("expected an error evaluating into %T", strs)
+	}
+	err = tbl.Eval("NONE * 2", &flux)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid expression")
+	}
+
+	err = tbl.AddComputedColumn("FLUX", "COUNTS / EXPTIME", "")
+	if err != nil {
+		t.Fatalf("table.AddComputedColumn: %v", err)
+	}
+	err = tbl.AddComputedColumn("TWICE", "COUNTS * 2", "1J")
+	if err != nil {
+		t.Fatalf("table.AddComputedColumn: %v", err)
+	}
+	if tbl.NumCols() != 4 {
+		t.Fatalf("expected 4 columns. got %d", tbl.NumCols())
+	}
+	if format := tbl.Col(2).Format; format != "1D" {
+		t.Fatalf("expected TFORM3=1D. got %q", format)
+	}
+
+	var (
+		fluxes []float64
+		twices []int32
+	)
+	err = tbl.ReadColumn("FLUX", 0, tbl.NumRows(), &fluxes)
+	if err != nil {
+		t.Fatalf("error reading column: %v", err)
+	}
+	if !reflect.DeepEqual(fluxes, flux) {
+		t.Fatalf("expected\nref=%v\ngot=%v", flux, fluxes)
+	}
+	err = tbl.ReadColumn("TWICE", 0, tbl.NumRows(), &twices)
+	if err != nil {
+		t.Fatalf("error reading column: %v", err)
+	}
+	if want := []int32{2, 4, 6, 8}; !reflect.DeepEqual(twices, want) {
+		t.Fatalf("expected\nref=%v\ngot=%v", want, twices)
+	}
+}
+
+func TestTableReadColumns(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	type Data struct {
+		N int64   `fits:"n"`
+		X float64 `fits:"x"`
+		S string  `fits:"s,format=8A"`
+	}
+	data := []Data{{1, 1.5, "a"}, {2, 2.5, "b"}, {3, 3.5, "c"}}
+
+	tbl, err := NewTableFromStruct(f, "test", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	_, err = tbl.ReadColumns(0, tbl.NumRows(), "x", "none")
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+
+	rows, err := tbl.ReadColumns(0, tbl.NumRows(), "x", "n")
+	if err != nil {
+		t.Fatalf("table.ReadColumns: %v", err)
+	}
+	defer rows.Close()
+	if names := rows.Columns(); !reflect.DeepEqual(names, []string{"x", "n"}) {
+		t.Fatalf("expected columns [x n]. got %v", names)
+	}
+
+	irow := 0
+	for rows.Next() {
+		var (
+			x float64
+			n int64
+		)
+		err = rows.Scan(&x, &n)
+		if err != nil {
+			t.Fatalf("rows.Scan: %v", err)
+		}
+		if x != data[irow].X || n != data[irow].N {
+			t.Fatalf("row #%d: invalid values (%v, %v)", irow, x, n)
+		}
+
+		var row Data
+		err = rows.Scan(&row)
+		if err != nil {
+			t.Fatalf("rows.Scan: %v", err)
+		}
+		want := data[irow]
+		want.S = ""
+		if !reflect.DeepEqual(row, want) {
+			t.Fatalf("row #%d:\nexpected=%+v\ngot=%+v", irow, want, row)
+		}
+
+		m := make(map[string]interface{})
+		err = rows.Scan(&m)
+		if err != nil {
+			t.Fatalf("rows.Scan: %v", err)
+		}
+		if !reflect.DeepEqual(m, map[string]interface{}{"x": want.X, "n": want.N}) {
+			t.Fatalf("row #%d: invalid map %v", irow, m)
+		}
+		irow++
+	}
+	if irow != len(data) {
+		t.Fatalf("expected [%v] rows. got [%v]", len(data), irow)
+	}
+}
+
+func TestTableSort(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	type Data struct {
+		N int64   `fits:"n"`
+		X float64 `fits:"x"`
+		S string  `fits:"s,format=8A"`
+	}
+	data := []Data{
+		{2, 1.5, "a"},
+		{1, 2.5, "b"},
+		{2, 0.5, "c"},
+		{1, 2.5, "d"},
+		{3, 1.0, "e"},
+	}
+
+	tbl, err := NewTableFromStruct(f, "test", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	out, err := NewTableFromStruct(f, "sorted", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer out.Close()
+
+	readAll := func(tbl *Table) []Data {
+		var rows []Data
+		err := tbl.Data(&rows)
+		if err != nil {
+			t.Fatalf("table.Data: %v", err)
+		}
+		return rows
+	}
+
+	err = tbl.Sort()
+	if err == nil {
+		t.Fatalf("expected an error without sort keys")
+	}
+	err = tbl.Sort(SortKey{Name: "none"})
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+
+	err = tbl.SortTo(out, SortKey{Name: "x", Desc: true})
+	if err != nil {
+		t.Fatalf("table.SortTo: %v", err)
+	}
+	want := []Data{data[1], data[3], data[0], data[4], data[2]}
+	if got := readAll(out); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortTo:\nexpected=%+v\ngot=%+v", want, got)
+	}
+	if got := readAll(tbl); !reflect.DeepEqual(got, data) {
+		t.Fatalf("SortTo modified the source table:\nexpected=%+v\ngot=%+v", data, got)
+	}
+
+	err = tbl.Sort(SortKey{Name: "n"}, SortKey{Name: "x", Desc: true})
+	if err != nil {
+		t.Fatalf("table.Sort: %v", err)
+	}
+	want = []Data{data[1], data[3], data[0], data[2], data[4]}
+	if got := readAll(tbl); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sort:\nexpected=%+v\ngot=%+v", want, got)
+	}
+
+	err = tbl.Sort(SortKey{Name: "s", Desc: true})
+	if err != nil {
+		t.Fatalf("table.Sort: %v", err)
+	}
+	want = []Data{data[4], data[3], data[2], data[1], data[0]}
+	if got := readAll(tbl); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sort:\nexpected=%+v\ngot=%+v", want, got)
+	}
+
+	// unsigned 64-bit integers, NaNs and several strings per row
+	tbl, err = NewTable(f, "special", []Column{
+		{Name: "u", Format: "K", Bzero: 1 << 63},
+		{Name: "x", Format: "D"},
+		{Name: "w", Format: "8A4"},
+	}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	nan := math.NaN()
+	err = tbl.WriteColumn("u", 0, []uint64{1 << 63, 1, math.MaxUint64, 0})
+	if err != nil {
+		t.Fatalf("error writing column [u]: %v", err)
+	}
+	err = tbl.WriteColumn("x", 0, []float64{2, nan, 1, nan})
+	if err != nil {
+		t.Fatalf("error writing column [x]: %v", err)
+	}
+	err = tbl.WriteColumn("w", 0, []string{"d", "a", "c", "b", "b", "c", "a", "d"})
+	if err != nil {
+		t.Fatalf("error writing column [w]: %v", err)
+	}
+	err = tbl.Sort(SortKey{Name: "w"})
+	if err == nil {
+		t.Fatalf("expected an error sorting by a column of several strings per row")
+	}
+
+	for _, tc := range []struct {
+		key  SortKey
+		name string
+		want interface{}
+	}{
+		{SortKey{Name: "u"}, "u", []uint64{0, 1, 1 << 63, math.MaxUint64}},
+		{SortKey{Name: "u", Desc: true}, "u", []uint64{math.MaxUint64, 1 << 63, 1, 0}},
+		{SortKey{Name: "x"}, "u", []uint64{math.MaxUint64, 1 << 63, 1, 0}},
+		{SortKey{Name: "x", Desc: true}, "u", []uint64{1 << 63, math.MaxUint64, 1, 0}},
+	} {
+		err = tbl.Sort(tc.key)
+		if err != nil {
+			t.Fatalf("table.Sort(%+v): %v", tc.key, err)
+		}
+		got := reflect.New(reflect.TypeOf(tc.want))
+		err = tbl.ReadColumn(tc.name, 0, tbl.NumRows(), got.Interface())
+		if err != nil {
+			t.Fatalf("error reading column [%s]: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), tc.want) {
+			t.Fatalf("Sort(%+v):\nexpected=%v\ngot=%v", tc.key, tc.want, got.Elem().Interface())
+		}
+	}
+}
+
+func TestTableHistogram(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	type Event struct {
+		X float64 `fits:"x"`
+		Y float64 `fits:"y"`
+		W float64 `fits:"w"`
+	}
+	events := []Event{
+		{0.5, 0.5, 1},
+		{1.5, 0.5, 2},
+		{1.5, 1.5, 3},
+		{3.5, 2.5, 4},
+	}
+
+	tbl, err := NewTableFromStruct(f, "events", Event{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := range events {
+		err = tbl.Write(&events[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	_, err = tbl.Histogram([]string{"x", "none"}, HistOptions{})
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+	_, err = tbl.Histogram([]string{"x", "y"}, HistOptions{Binsize: []float64{1}})
+	if err == nil {
+		t.Fatalf("expected an error for an invalid number of bin sizes")
+	}
+
+	for _, table := range []struct {
+		opts HistOptions
+		want []float64
+	}{
+		{
+			opts: HistOptions{
+				Bitpix:  -64,
+				Min:     []float64{0, 0},
+				Max:     []float64{4, 4},
+				Binsize: []float64{1, 2},
+			},
+			want: []float64{1, 2, 0, 0, 0, 0, 0, 1},
+		},
+		{
+			opts: HistOptions{
+				Bitpix:  -64,
+				Min:     []float64{0, 0},
+				Max:     []float64{4, 4},
+				Binsize: []float64{1, 2},
+				Weight:  "w",
+			},
+			want: []float64{1, 5, 0, 0, 0, 0, 0, 4},
+		},
+		{
+			opts: HistOptions{
+				Bitpix:  -64,
+				Min:     []float64{0, 0},
+				Max:     []float64{4, 4},
+				Binsize: []float64{1, 2},
+				Where:   "w > 1",
+			},
+			want: []float64{0, 2, 0, 0, 0, 0, 0, 1},
+		},
+	} {
+		img, err := tbl.Histogram([]string{"x", "y"}, table.opts)
+		if err != nil {
+			t.Fatalf("table.Histogram(%+v): %v", table.opts, err)
+		}
+
+		hdr := img.Header()
+		if axes := hdr.Axes(); !reflect.DeepEqual(axes, []int64{4, 2}) {
+			t.Fatalf("expected image axes [4 2]. got %v", axes)
+		}
+		card := hdr.Get("CTYPE1")
+		if card == nil || card.Value != "x" {
+			t.Fatalf("expected CTYPE1=x. got %v", card)
+		}
+
+		data := make([]float64, len(table.want))
+		err = img.Data(&data)
+		if err != nil {
+			t.Fatalf("error reading image: %v", err)
+		}
+		if !reflect.DeepEqual(data, table.want) {
+			t.Fatalf("histogram(%+v):\nexpected=%v\ngot=%v", table.opts, table.want, data)
+		}
+	}
+}
+
+func TestTableHeap(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	_, err := NewTable(f, "test", []Column{{Name: "p", Value: []float64{}, Descriptor: 'X'}}, BINARY_TBL)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid descriptor")
+	}
+
+	cols := []Column{
+		{Name: "p", Value: []float64{}, Descriptor: 'P', MaxLen: 4},
+		{Name: "q", Value: []int32{}},
+	}
+	tbl, err := NewTable(f, "test", cols, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for _, table := range []struct {
+		name   string
+		desc   byte
+		maxlen int64
+	}{
+		{"p", 'P', 4},
+		{"q", 'Q', 0},
+	} {
+		col := tbl.Col(tbl.Index(table.name))
+		if !col.IsVLA || col.Descriptor != table.desc || col.MaxLen != table.maxlen {
+			t.Fatalf("column [%s]: invalid VLA description (format=%q, vla=%v, desc=%q, maxlen=%d)",
+				table.name, col.Format, col.IsVLA, col.Descriptor, col.MaxLen,
+			)
+		}
+	}
+
+	type Data struct {
+		P []float64 `fits:"p"`
+		Q []int32   `fits:"q"`
+	}
+	data := []Data{
+		{[]float64{1}, []int32{1, 2}},
+		{[]float64{2, 3}, []int32{3}},
+		{[]float64{4, 5, 6}, []int32{4, 5}},
+	}
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	info, err := tbl.TestHeap()
+	if err != nil {
+		t.Fatalf("table.TestHeap: %v", err)
+	}
+	if !info.Valid || info.Unused != 0 {
+		t.Fatalf("invalid heap: %+v", info)
+	}
+
+	// rewriting a longer array leaves a hole in the heap
+	data[0].P = []float64{7, 8, 9, 10}
+	err = tbl.WriteColumn("p", 0, [][]float64{data[0].P})
+	if err != nil {
+		t.Fatalf("table.WriteColumn: %v", err)
+	}
+
+	info, err = tbl.TestHeap()
+	if err != nil {
+		t.Fatalf("table.TestHeap: %v", err)
+	}
+	if !info.Valid || info.Unused == 0 {
+		t.Fatalf("expected unused bytes in the heap: %+v", info)
+	}
+
+	err = tbl.CompressHeap()
+	if err != nil {
+		t.Fatalf("table.CompressHeap: %v", err)
+	}
+
+	info, err = tbl.TestHeap()
+	if err != nil {
+		t.Fatalf("table.TestHeap: %v", err)
+	}
+	if !info.Valid || info.Unused != 0 {
+		t.Fatalf("invalid compressed heap: %+v", info)
+	}
+
+	var rows []Data
+	err = tbl.Data(&rows)
+	if err != nil {
+		t.Fatalf("table.Data: %v", err)
+	}
+	if !reflect.DeepEqual(rows, data) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data, rows)
+	}
+
+	// reserved heap
+	_, err = NewTableWithHeap(f, "bad", []Column{{Name: "q", Value: []int32{}}}, -1)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid heap size")
+	}
+	res, err := NewTableWithHeap(f, "reserved", []Column{{Name: "q", Value: []int32{}}}, 1000)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer res.Close()
+
+	info, err = res.TestHeap()
+	if err != nil {
+		t.Fatalf("table.TestHeap: %v", err)
+	}
+	if !info.Valid || info.Start != 0 || info.Size != 1000 || info.Unused != 1000 {
+		t.Fatalf("invalid reserved heap: %+v", info)
+	}
+
+	err = res.WriteColumn("q", 0, [][]int32{{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("table.WriteColumn: %v", err)
+	}
+	info, err = res.TestHeap()
+	if err != nil {
+		t.Fatalf("table.TestHeap: %v", err)
+	}
+	hdr := res.Header()
+	if start := hdr.Axes()[0]; !info.Valid || info.Start != start || info.Size != 1012 || info.Unused != 1000 {
+		t.Fatalf("invalid reserved heap (expected start=%d): %+v", start, info)
+	}
+
+	var qs [][]int32
+	err = res.ReadColumn("q", 0, res.NumRows(), &qs)
+	if err != nil {
+		t.Fatalf("table.ReadColumn: %v", err)
+	}
+	if !reflect.DeepEqual(qs, [][]int32{{1, 2, 3}}) {
+		t.Fatalf("invalid q values: %v", qs)
+	}
+}
+
+func TestTableGetSet(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	type Data struct {
+		N int64      `fits:"n"`
+		V [3]float32 `fits:"v"`
+		A []float64  `fits:"a"`
+		S string     `fits:"s,format=8A"`
+	}
+	data := []Data{
+		{1, [3]float32{1, 2, 3}, []float64{1}, "a"},
+		{2, [3]float32{4, 5, 6}, []float64{2, 3}, "b"},
+		{3, [3]float32{7, 8, 9}, []float64{4, 5, 6}, "c"},
+	}
+
+	tbl, err := NewTableFromStruct(f, "test", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	var n int64
+	err = tbl.Get(3, "n", &n)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid row")
+	}
+	err = tbl.Get(0, "none", &n)
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+	err = tbl.Set(1, "none", n)
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+
+	err = tbl.Get(1, "n", &n)
+	if err != nil {
+		t.Fatalf("table.Get: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected n=2. got %v", n)
+	}
+
+	var v [3]float32
+	err = tbl.Get(2, "v", &v)
+	if err != nil {
+		t.Fatalf("table.Get: %v", err)
+	}
+	if v != data[2].V {
+		t.Fatalf("expected v=%v. got %v", data[2].V, v)
+	}
+
+	data[1].N = 42
+	data[1].V = [3]float32{-1, -2, -3}
+	data[1].A = []float64{10, 20, 30, 40}
+	data[1].S = "bbb"
+	for _, cell := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"n", data[1].N},
+		{"v", &data[1].V},
+		{"a", data[1].A},
+		{"s", data[1].S},
+	} {
+		err = tbl.Set(1, cell.name, cell.value)
+		if err != nil {
+			t.Fatalf("table.Set(%q): %v", cell.name, err)
+		}
+	}
+
+	var a []float64
+	err = tbl.Get(1, "a", &a)
+	if err != nil {
+		t.Fatalf("table.Get: %v", err)
+	}
+	if !reflect.DeepEqual(a, data[1].A) {
+		t.Fatalf("expected a=%v. got %v", data[1].A, a)
+	}
+
+	data[0] = Data{10, [3]float32{0, 0, 1}, []float64{0.5}, "z"}
+	err = tbl.WriteRow(0, &data[0])
+	if err != nil {
+		t.Fatalf("table.WriteRow: %v", err)
+	}
+
+	data[2].N = 30
+	err = tbl.WriteRow(2, map[string]interface{}{"n": data[2].N})
+	if err != nil {
+		t.Fatalf("table.WriteRow: %v", err)
+	}
+
+	row := Data{4, [3]float32{1, 1, 1}, []float64{1, 1}, "d"}
+	err = tbl.WriteRow(tbl.NumRows(), &row)
+	if err != nil {
+		t.Fatalf("table.WriteRow: %v", err)
+	}
+	data = append(data, row)
+	if tbl.NumRows() != int64(len(data)) {
+		t.Fatalf("expected [%v] rows. got [%v]", len(data), tbl.NumRows())
+	}
+
+	err = tbl.WriteRow(0, 42)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid row value")
+	}
+
+	var rows []Data
+	err = tbl.Data(&rows)
+	if err != nil {
+		t.Fatalf("table.Data: %v", err)
+	}
+	if !reflect.DeepEqual(rows, data) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data, rows)
+	}
+
+	for _, value := range []interface{}{(*[3]float32)(nil), nil} {
+		err = tbl.Set(3, "v", value)
+		if err != nil {
+			t.Fatalf("table.Set(%v): %v", value, err)
+		}
+		v = [3]float32{}
+		err = tbl.Get(3, "v", &v)
+		if err != nil {
+			t.Fatalf("table.Get: %v", err)
+		}
+		for _, x := range v {
+			if !math.IsNaN(float64(x)) {
+				t.Fatalf("expected an undefined cell. got v=%v", v)
+			}
+		}
+	}
+}
+
+func TestCopyTable(t *testing.T) {
+	type Data struct {
+		N int64     `fits:"n"`
+		X float64   `fits:"x"`
+		S string    `fits:"s,format=8A"`
+		A []float64 `fits:"a"`
+	}
+	data := []Data{
+		{1, 1.5, "a", []float64{1}},
+		{2, 2.5, "b", []float64{2, 2}},
+		{3, 3.5, "c", []float64{3, 3, 3}},
+		{4, 4.5, "d", []float64{4}},
+		{5, 5.5, "e", []float64{5, 5}},
+	}
+
+	var files []*File
+	for i := 0; i < 2; i++ {
+		f, done := newTestFile(t)
+		defer done()
+		files = append(files, f)
+	}
+
+	src, err := NewTableFromStruct(files[0], "src", Data{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer src.Close()
+
+	for i := range data {
+		err = src.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	readAll := func(tbl *Table) []Data {
+		var rows []Data
+		err := tbl.Data(&rows)
+		if err != nil {
+			t.Fatalf("table.Data: %v", err)
+		}
+		return rows
+	}
+
+	for i, f := range files {
+		dst, err := NewTableFromStruct(f, "dst", Data{}, BINARY_TBL)
+		if err != nil {
+			t.Fatalf("error creating new table: %v", err)
+		}
+		defer dst.Close()
+
+		err = CopyTableRange(dst, src, 3, 6)
+		if err == nil {
+			t.Fatalf("file #%d: expected an error for an invalid rows range", i)
+		}
+
+		err = CopyTableRange(dst, src, 1, 4)
+		if err != nil {
+			t.Fatalf("file #%d: CopyTableRange: %v", i, err)
+		}
+		err = CopyTableWhere(dst, src, "n > 3")
+		if err != nil {
+			t.Fatalf("file #%d: CopyTableWhere: %v", i, err)
+		}
+		err = CopyTable(dst, src)
+		if err != nil {
+			t.Fatalf("file #%d: CopyTable: %v", i, err)
+		}
+
+		want := append(append(append([]Data{}, data[1:4]...), data[3:]...), data...)
+		if got := readAll(dst); !reflect.DeepEqual(got, want) {
+			t.Fatalf("file #%d:\nexpected=%+v\ngot=%+v", i, want, got)
+		}
+	}
+
+	type Other struct {
+		N int32 `fits:"n"`
+	}
+	bad, err := NewTableFromStruct(files[1], "bad", Other{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer bad.Close()
+
+	err = CopyTable(bad, src)
+	if err == nil {
+		t.Fatalf("expected an error for tables with different columns")
+	}
+
+	err = CopyTableColumns(bad, src, map[string]string{"none": "none"})
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+
+	for i, f := range files {
+		other, err := NewTableFromStruct(f, "other", Other{}, BINARY_TBL)
+		if err != nil {
+			t.Fatalf("error creating new table: %v", err)
+		}
+		defer other.Close()
+
+		err = CopyTableColumns(other, src, map[string]string{"n": "n", "x": "y"})
+		if err != nil {
+			t.Fatalf("file #%d: CopyTableColumns: %v", i, err)
+		}
+		if other.NumRows() != src.NumRows() {
+			t.Fatalf("file #%d: expected [%v] rows. got [%v]", i, src.NumRows(), other.NumRows())
+		}
+
+		var (
+			n []int32
+			y []float64
+		)
+		err = other.ReadColumn("n", 0, other.NumRows(), &n)
+		if err != nil {
+			t.Fatalf("file #%d: ReadColumn: %v", i, err)
+		}
+		err = other.ReadColumn("y", 0, other.NumRows(), &y)
+		if err != nil {
+			t.Fatalf("file #%d: ReadColumn: %v", i, err)
+		}
+		for j := range data {
+			if int64(n[j]) != data[j].N || y[j] != data[j].X {
+				t.Fatalf("file #%d: row #%d: invalid values (%v, %v)", i, j, n[j], y[j])
+			}
+		}
+	}
+}
+
+func TestCopyTableLayout(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	// same formats, different scalings and null values
+	src, err := NewTable(f, "src", []Column{
+		{Name: "n", Format: "J", Null: int64(-1)},
+		{Name: "x", Format: "J", Bscale: 0.5},
+	}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer src.Close()
+
+	err = src.WriteColumn("n", 0, []int64{1, 2, 3})
+	if err != nil {
+		t.Fatalf("error writing column [n]: %v", err)
+	}
+	err = src.WriteColumn("x", 0, []float64{1.5, 2, 2.5})
+	if err != nil {
+		t.Fatalf("error writing column [x]: %v", err)
+	}
+	err = src.Set(1, "n", nil)
+	if err != nil {
+		t.Fatalf("table.Set: %v", err)
+	}
+
+	dst, err := NewTable(f, "dst", []Column{
+		{Name: "n", Format: "J", Null: int64(-99)},
+		{Name: "x", Format: "J", Bscale: 0.25},
+	}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer dst.Close()
+
+	err = CopyTable(dst, src)
+	if err != nil {
+		t.Fatalf("CopyTable: %v", err)
+	}
+
+	type Data struct {
+		N *int64  `fits:"n"`
+		X float64 `fits:"x"`
+	}
+	var rows []Data
+	err = dst.Data(&rows)
+	if err != nil {
+		t.Fatalf("table.Data: %v", err)
+	}
+	if len(rows) != 3 || rows[1].N != nil || *rows[0].N != 1 || *rows[2].N != 3 {
+		t.Fatalf("invalid n values: %+v", rows)
+	}
+	for i, want := range []float64{1.5, 2, 2.5} {
+		if rows[i].X != want {
+			t.Fatalf("row #%d: expected x=%v. got %v", i, want, rows[i].X)
+		}
+	}
+
+	// same formats, different columns positions
+	cols := func(start int64) []Column {
+		return []Column{
+			{Name: "id", Format: "I4"},
+			{Name: "s", Format: "A6", Start: start},
+		}
+	}
+	asrc, err := NewTable(f, "asrc", cols(0), ASCII_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer asrc.Close()
+
+	type Row struct {
+		ID int64  `fits:"id"`
+		S  string `fits:"s"`
+	}
+	want := []Row{{1, "a"}, {2, "bbbbbb"}}
+	for i := range want {
+		err = asrc.Write(&want[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	adst, err := NewTable(f, "adst", cols(10), ASCII_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer adst.Close()
+
+	err = CopyTable(adst, asrc)
+	if err != nil {
+		t.Fatalf("CopyTable: %v", err)
+	}
+	var got []Row
+	err = adst.Data(&got)
+	if err != nil {
+		t.Fatalf("table.Data: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected=%+v\ngot=%+v", want, got)
+	}
+}
+
+func TestMergeTables(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	type A struct {
+		N int32   `fits:"n"`
+		X float32 `fits:"x"`
+		S string  `fits:"s,format=4A"`
+	}
+	type B struct {
+		N int64   `fits:"n"`
+		S string  `fits:"s,format=8A"`
+		E float64 `fits:"e"`
+	}
+	type C struct {
+		N string `fits:"n,format=4A"`
+	}
+
+	a, err := NewTableFromStruct(f, "a", A{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer a.Close()
+	for _, row := range []A{{1, 1.5, "a"}, {2, 2.5, "bb"}} {
+		err = a.Write(&row)
+		if err != nil {
+			t.Fatalf("error writing row: %v", err)
+		}
+	}
+
+	b, err := NewTableFromStruct(f, "b", B{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer b.Close()
+	for _, row := range []B{{3, "cccccc", 3.5}, {1 << 40, "d", 4.5}} {
+		err = b.Write(&row)
+		if err != nil {
+			t.Fatalf("error writing row: %v", err)
+		}
+	}
+
+	c, err := NewTableFromStruct(f, "c", C{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer c.Close()
+
+	if !a.Schema().Equal(a.Schema()) {
+		t.Fatalf("expected equal schemas")
+	}
+	if a.Schema().Equal(b.Schema()) {
+		t.Fatalf("expected different schemas")
+	}
+
+	diffs := a.Schema().Diff(b.Schema())
+	if len(diffs) != 4 {
+		t.Fatalf("expected 4 differences. got %v", diffs)
+	}
+	for i, want := range []struct {
+		name     string
+		old, new bool
+	}{
+		{"n", true, true},
+		{"x", true, false},
+		{"s", true, true},
+		{"e", false, true},
+	} {
+		diff := diffs[i]
+		if diff.Name != want.name || (diff.Old != nil) != want.old || (diff.New != nil) != want.new {
+			t.Fatalf("diff #%d: expected %+v. got %v", i, want, diff)
+		}
+	}
+
+	bad, err := NewTableFromStruct(f, "bad", A{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer bad.Close()
+
+	err = MergeTables(bad, a, c)
+	if err == nil {
+		t.Fatalf("expected an error for incompatible columns")
+	}
+
+	dst, err := NewTableFromStruct(f, "merged", A{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer dst.Close()
+
+	err = MergeTables(dst, a, b)
+	if err != nil {
+		t.Fatalf("MergeTables: %v", err)
+	}
+	if dst.NumRows() != 4 {
+		t.Fatalf("expected 4 rows. got %v", dst.NumRows())
+	}
+
+	for _, table := range []struct {
+		name string
+		code string
+	}{
+		{"n", "K"},
+		{"x", "E"},
+		{"s", "A"},
+		{"e", "D"},
+	} {
+		icol := dst.Index(table.name)
+		if icol < 0 {
+			t.Fatalf("expected a column named [%s]", table.name)
+		}
+		_, code, err := parseTForm(dst.Col(icol).Format)
+		if err != nil || code != table.code {
+			t.Fatalf("column [%s]: expected format %q. got %q", table.name, table.code, dst.Col(icol).Format)
+		}
+	}
+
+	var (
+		n  []int64
+		x  []float64
+		ss []string
+		e  []float64
+	)
+	for _, col := range []struct {
+		name string
+		ptr  interface{}
+	}{
+		{"n", &n},
+		{"x", &x},
+		{"s", &ss},
+		{"e", &e},
+	} {
+		err = dst.ReadColumn(col.name, 0, dst.NumRows(), col.ptr)
+		if err != nil {
+			t.Fatalf("ReadColumn(%q): %v", col.name, err)
+		}
+	}
+	if !reflect.DeepEqual(n, []int64{1, 2, 3, 1 << 40}) {
+		t.Fatalf("invalid n values: %v", n)
+	}
+	if !reflect.DeepEqual(ss, []string{"a", "bb", "cccccc", "d"}) {
+		t.Fatalf("invalid s values: %v", ss)
+	}
+	if x[0] != 1.5 || x[1] != 2.5 || !math.IsNaN(x[2]) || !math.IsNaN(x[3]) {
+		t.Fatalf("invalid x values: %v", x)
+	}
+	if !math.IsNaN(e[0]) || !math.IsNaN(e[1]) || e[2] != 3.5 || e[3] != 4.5 {
+		t.Fatalf("invalid e values: %v", e)
+	}
+}
+
+func TestMergeScaledTables(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	a, err := NewTable(f, "a", []Column{{Name: "x", Format: "I", Bscale: 0.5}}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer a.Close()
+	err = a.WriteColumn("x", 0, []float64{1.5, 2.5})
+	if err != nil {
+		t.Fatalf("error writing column [x]: %v", err)
+	}
+
+	b, err := NewTable(f, "b", []Column{{Name: "x", Format: "J", Bscale: 0.5}}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer b.Close()
+	err = b.WriteColumn("x", 0, []float64{-3.5})
+	if err != nil {
+		t.Fatalf("error writing column [x]: %v", err)
+	}
+
+	err = MergeTables(a, b)
+	if err != nil {
+		t.Fatalf("MergeTables: %v", err)
+	}
+	if format := a.Col(0).Format; format != "1D" {
+		t.Fatalf("expected TFORM1=1D. got %q", format)
+	}
+	var x []float64
+	err = a.ReadColumn("x", 0, a.NumRows(), &x)
+	if err != nil {
+		t.Fatalf("error reading column [x]: %v", err)
+	}
+	if want := []float64{1.5, 2.5, -3.5}; !reflect.DeepEqual(x, want) {
+		t.Fatalf("expected x=%v. got %v", want, x)
+	}
+}
+
+func TestASCIITable(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cols := []Column{
+		{Name: "id", Value: int64(0), Display: "I4"},
+		{Name: "x", Value: float64(0), Display: "F8.3"},
+		{Name: "s", Value: "", Format: "A6", Start: 20},
+		{Name: "ok", Value: false},
+	}
+	tbl, err := NewTable(f, "ascii", cols, ASCII_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	for _, bad := range [][]Column{
+		{{Name: "a", Format: "I4"}, {Name: "b", Format: "I4", Start: 3}},
+		{{Name: "a", Format: "I4", Start: 10}, {Name: "b", Format: "A12", Start: 1}},
+		{{Name: "a", Format: "I4", Start: -1}},
+	} {
+		_, err = NewTable(f, "bad", bad, ASCII_TBL)
+		if err == nil {
+			t.Fatalf("expected an error for the columns layout %+v", bad)
+		}
+	}
+
+	for i, want := range []struct {
+		format string
+		start  int64
+	}{
+		{"I4", 1},
+		{"F8.3", 6},
+		{"A6", 20},
+		{"A1", 27},
+	} {
+		col := tbl.Col(i)
+		if col.Format != want.format || col.Start != want.start {
+			t.Fatalf("column [%s]: expected (%q, %d). got (%q, %d)",
+				col.Name, want.format, want.start, col.Format, col.Start,
+			)
+		}
+	}
+	hdr := tbl.Header()
+	if axes := hdr.Axes(); axes[0] != 27 {
+		t.Fatalf("expected NAXIS1=27. got %v", axes)
+	}
+
+	type Data struct {
+		ID int64   `fits:"id"`
+		X  float64 `fits:"x"`
+		S  string  `fits:"s"`
+		OK bool    `fits:"ok"`
+	}
+	data := []Data{
+		{1, 1.5, "a", true},
+		{2, -2.25, "bbbbbb", false},
+	}
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	var rows []Data
+	err = tbl.Data(&rows)
+	if err != nil {
+		t.Fatalf("table.Data: %v", err)
+	}
+	if !reflect.DeepEqual(rows, data) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data, rows)
+	}
+
+	str, err := tbl.Col(1).FormatValue(data[1].X)
+	if err != nil {
+		t.Fatalf("FormatValue: %v", err)
+	}
+	if str != "  -2.250" {
+		t.Fatalf("expected %q. got %q", "  -2.250", str)
+	}
+}
+
+func TestColumnFormatValue(t *testing.T) {
+	for _, table := range []struct {
+		col   Column
+		value interface{}
+		want  string
+	}{
+		{Column{Display: "I5"}, 42, "   42"},
+		{Column{Display: "I5.3"}, int16(-7), " -007"},
+		{Column{Display: "I2"}, 123, "**"},
+		{Column{Display: "Z4"}, uint8(255), "  FF"},
+		{Column{Display: "B8.8"}, 5, "00000101"},
+		{Column{Display: "O3"}, 8, " 10"},
+		{Column{Display: "F8.3"}, 3.14159, "   3.142"},
+		{Column{Display: "F8.3"}, float32(2), "   2.000"},
+		{Column{Display: "E12.4"}, 1234.56, "  0.1235E+04"},
+		{Column{Display: "E12.4E3"}, -1234.56, "-0.1235E+004"},
+		{Column{Display: "D12.4"}, 0.000123456, "  0.1235D-03"},
+		{Column{Display: "ES10.3"}, 1234.56, " 1.235E+03"},
+		{Column{Display: "EN10.2"}, 12345.6, " 12.35E+03"},
+		{Column{Display: "G10.3"}, 12.345, "  12.3    "},
+		{Column{Display: "G10.3"}, 12345.0, " 0.123E+05"},
+		{Column{Display: "A4"}, "hello", "hell"},
+		{Column{Display: "A6"}, "ab", "    ab"},
+		{Column{Display: "L3"}, true, "  T"},
+		{Column{Display: "F6.2"}, NullFloat64{Float64: 1, Valid: false}, "      "},
+		{Column{Format: "F6.2"}, 1.5, "  1.50"},
+		{Column{Format: "1D"}, 1.5, "1.5"},
+	} {
+		str, err := table.col.FormatValue(table.value)
+		if err != nil {
+			t.Fatalf("FormatValue(%q, %v): %v", table.col.Display, table.value, err)
+		}
+		if str != table.want {
+			t.Fatalf("FormatValue(%q, %v): expected %q. got %q", table.col.Display, table.value, table.want, str)
+		}
+	}
+
+	for _, table := range []struct {
+		col   Column
+		value interface{}
+	}{
+		{Column{Display: "I5"}, "str"},
+		{Column{Display: "A5"}, 42},
+		{Column{Display: "Q3"}, 42},
+	} {
+		_, err := table.col.FormatValue(table.value)
+		if err == nil {
+			t.Fatalf("FormatValue(%q, %v): expected an error", table.col.Display, table.value)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	for _, table := range []struct {
+		str  string
+		want time.Time
+	}{
+		{"2000-01-01", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2000-01-01T12:34:56", time.Date(2000, 1, 1, 12, 34, 56, 0, time.UTC)},
+		{"2000-01-01T12:34:56.125", time.Date(2000, 1, 1, 12, 34, 56, 125000000, time.UTC)},
+		{"31/12/99", time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+	} {
+		got, err := ParseTime(table.str)
+		if err != nil {
+			t.Fatalf("error parsing %q: %v", table.str, err)
+		}
+		if !got.Equal(table.want) {
+			t.Fatalf("%q: expected %v. got %v", table.str, table.want, got)
+		}
+	}
+
+	_, err := ParseTime("2000-13-01")
+	if err == nil {
+		t.Fatalf("expected an error parsing an invalid date")
+	}
+
+	tt := time.Date(2000, 1, 1, 12, 34, 56, 999999999, time.UTC)
+	for _, table := range []struct {
+		decimals int
+		want     string
+	}{
+		{-1, "2000-01-01"},
+		{0, "2000-01-01T12:34:56"},
+		{3, "2000-01-01T12:34:56.999"},
+	} {
+		got, err := FormatTime(tt, table.decimals)
+		if err != nil {
+			t.Fatalf("error formatting %v: %v", tt, err)
+		}
+		if got != table.want {
+			t.Fatalf("decimals=%d: expected %q. got %q", table.decimals, table.want, got)
+		}
+	}
+
+	hdr := NewHeader(
+		[]Card{
+			{Name: "DATE-OBS", Value: "2000-01-01T12:01:04.184"},
+			{Name: "MJD-OBS", Value: 51544.5},
+			{Name: "JD-OBS", Value: 2451545.0},
+			{Name: "TIMESYS", Value: "TT"},
+			{Name: "MJDREF", Value: 51544.0},
+			{Name: "TIMEZERO", Value: 60.0},
+			{Name: "TSTART", Value: 43140.0},
+			{Name: "OBJECT", Value: 42.0},
+		},
+		IMAGE_HDU, 8, nil,
+	)
+	for _, table := range []struct {
+		key  string
+		want time.Time
+	}{
+		{"DATE-OBS", time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"MJD-OBS", time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)},
+		{"JD-OBS", time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)},
+		{"TSTART", time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)},
+		{"TIMEZERO", time.Date(1999, 12, 31, 23, 59, 55, 816000000, time.UTC)},
+	} {
+		got, err := hdr.Time(table.key)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", table.key, err)
+		}
+		if d := got.Sub(table.want); d < -time.Microsecond || d > time.Microsecond {
+			t.Fatalf("%s: expected %v. got %v", table.key, table.want, got)
+		}
+	}
+	_, err = hdr.Time("NOTHERE")
+	if err == nil {
+		t.Fatalf("expected an error reading a missing keyword")
+	}
+
+	hdr.Set("TIMESYS", "TCB", "")
+	_, err = hdr.Time("MJD-OBS")
+	if err == nil {
+		t.Fatalf("expected an error with an unsupported time scale")
+	}
+}
+
+func TestTimeColumns(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cols := []Column{
+		{Name: "date", Value: time.Time{}},
+		{Name: "time", Format: "D"},
+		{Name: "mjd", Format: "D", Unit: "d"},
+	}
+	tbl, err := NewTable(f, "events", cols, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	if got := tbl.Col(0).Format; got != "23A" {
+		t.Fatalf("expected format %q. got %q", "23A", got)
+	}
+
+	// time reference: 2000-01-01T00:00:00 TT, in minutes
+	err = tbl.seekHDU()
+	if err != nil {
+		t.Fatalf("error seeking table: %v", err)
+	}
+	for _, card := range []Card{
+		{Name: "TIMESYS", Value: "TT"},
+		{Name: "MJDREFI", Value: int64(51544)},
+		{Name: "MJDREFF", Value: 0.0},
+		{Name: "TIMEZERO", Value: 10.0},
+		{Name: "TIMEUNIT", Value: "min"},
+	} {
+		err = writeCard(tbl.f, &card)
+		if err != nil {
+			t.Fatalf("error writing %s: %v", card.Name, err)
+		}
+	}
+	err = tbl.refresh()
+	if err != nil {
+		t.Fatalf("error refreshing table: %v", err)
+	}
+
+	type Event struct {
+		Date time.Time  `fits:"date"`
+		Time time.Time  `fits:"time"`
+		MJD  *time.Time `fits:"mjd"`
+	}
+	t0 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	t1 := time.Date(2017, 6, 30, 23, 59, 59, 500000000, time.UTC)
+	data := []Event{
+		{Date: t0, Time: t0, MJD: &t0},
+		{Date: t1, Time: t1, MJD: nil},
+	}
+	for i := range data {
+		err = tbl.Write(&data[i])
+		if err != nil {
+			t.Fatalf("error writing row [%v]: %v", i, err)
+		}
+	}
+
+	// raw values, in the TT time scale
+	var date string
+	err = tbl.Get(0, "date", &date)
+	if err != nil {
+		t.Fatalf("error reading date: %v", err)
+	}
+	if want := "2000-01-01T12:01:04.184"; date != want {
+		t.Fatalf("expected date %q. got %q", want, date)
+	}
+	for _, table := range []struct {
+		col  string
+		want float64
+	}{
+		{"time", (12*3600+64.184)/60 - 10},
+		{"mjd", 51544.5 + 64.184/86400},
+	} {
+		var v float64
+		err = tbl.Get(0, table.col, &v)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", table.col, err)
+		}
+		if math.Abs(v-table.want) > 1e-6 {
+			t.Fatalf("%s: expected %v. got %v", table.col, table.want, v)
+		}
+	}
+
+	near := func(a, b time.Time) bool {
+		d := a.Sub(b)
+		return -time.Millisecond < d && d < time.Millisecond
+	}
+
+	rows, err := tbl.Read(0, tbl.NumRows())
+	if err != nil {
+		t.Fatalf("error reading rows: %v", err)
+	}
+	defer rows.Close()
+	for i := 0; rows.Next(); i++ {
+		var evt Event
+		err = rows.Scan(&evt)
+		if err != nil {
+			t.Fatalf("error scanning row [%v]: %v", i, err)
+		}
+		want := data[i]
+		if !near(evt.Date, want.Date) || !near(evt.Time, want.Time) {
+			t.Fatalf("row [%v]: expected %+v. got %+v", i, want, evt)
+		}
+		if (evt.MJD == nil) != (want.MJD == nil) || (evt.MJD != nil && !near(*evt.MJD, *want.MJD)) {
+			t.Fatalf("row [%v]: expected mjd=%v. got %v", i, want.MJD, evt.MJD)
+		}
+
+		var (
+			date, tt time.Time
+			mjd      *time.Time
+		)
+		err = rows.Scan(&date, &tt, &mjd)
+		if err != nil {
+			t.Fatalf("error scanning row [%v]: %v", i, err)
+		}
+		if !near(date, want.Date) || !near(tt, want.Time) || (mjd == nil) != (want.MJD == nil) {
+			t.Fatalf("row [%v]: expected %+v. got (%v, %v, %v)", i, want, date, tt, mjd)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		t.Fatalf("error iterating rows: %v", err)
+	}
+}
+
 // EOF
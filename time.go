@@ -0,0 +1,433 @@
+package cfitsio
+
+// #include "go-cfitsio.h"
+// #include "go-cfitsio-utils.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// mjdEpoch is the origin of Modified Julian Dates (MJD 0).
+var mjdEpoch = time.Date(1858, time.November, 17, 0, 0, 0, 0, time.UTC)
+
+// ParseTime parses a FITS date string, "YYYY-MM-DD", "YYYY-MM-DDThh:mm:ss[.sss...]"
+// or the older "dd/mm/yy" form, into a UTC time.Time.
+func ParseTime(s string) (time.Time, error) {
+	c_str := C.CString(strings.TrimSpace(s))
+	defer C.free(unsafe.Pointer(c_str))
+	c_year := C.int(0)
+	c_month := C.int(0)
+	c_day := C.int(0)
+	c_hour := C.int(0)
+	c_minute := C.int(0)
+	c_second := C.double(0)
+	c_status := C.int(0)
+	C.fits_str2time(c_str, &c_year, &c_month, &c_day, &c_hour, &c_minute, &c_second, &c_status)
+	if c_status > 0 {
+		return time.Time{}, to_err(c_status)
+	}
+
+	sec := math.Floor(float64(c_second))
+	nsec := math.Floor((float64(c_second)-sec)*1e9 + 0.5)
+	return time.Date(
+		int(c_year), time.Month(c_month), int(c_day),
+		int(c_hour), int(c_minute), int(sec), int(nsec),
+		time.UTC,
+	), nil
+}
+
+// FormatTime formats t (in UTC) as a FITS date string, "YYYY-MM-DDThh:mm:ss"
+// followed by decimals digits of fractional seconds.
+// A negative decimals formats the date alone, "YYYY-MM-DD".
+func FormatTime(t time.Time, decimals int) (string, error) {
+	t = t.UTC()
+	if decimals >= 0 && decimals <= 9 {
+		// CFITSIO rounds the seconds: never round up to the next minute.
+		t = t.Truncate(time.Duration(math.Pow10(9 - decimals)))
+	}
+	sec := float64(t.Second()) + float64(t.Nanosecond())*1e-9
+
+	c_str := C.CStringN(C.FLEN_VALUE)
+	defer C.free(unsafe.Pointer(c_str))
+	c_status := C.int(0)
+	C.fits_time2str(
+		C.int(t.Year()), C.int(t.Month()), C.int(t.Day()),
+		C.int(t.Hour()), C.int(t.Minute()), C.double(sec),
+		C.int(decimals), c_str, &c_status,
+	)
+	if c_status > 0 {
+		return "", to_err(c_status)
+	}
+	return C.GoString(c_str), nil
+}
+
+// Time returns the value of the keyword n as a UTC time.Time.
+// String values are FITS date strings (e.g. DATE-OBS).
+// Numeric values are Modified Julian Dates for keywords starting with "MJD"
+// (e.g. MJD-OBS), Julian Dates for keywords starting with "JD" (e.g. JD-OBS),
+// and times relative to the time reference of the header (MJDREF, TIMEZERO,
+// TIMEUNIT, ...) otherwise (e.g. TSTART or TSTOP.)
+// Values are in the time scale given by the TIMESYS keyword (default: UTC.)
+func (h *Header) Time(n string) (time.Time, error) {
+	card := h.Get(n)
+	if card == nil {
+		return time.Time{}, fmt.Errorf("cfitsio: no keyword [%s]", n)
+	}
+	ref := newTimeRef(h)
+	if str, ok := card.Value.(string); ok {
+		return ref.parse(str)
+	}
+	v, ok := cardFloat(card.Value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cfitsio: invalid %s value [%v] (%T)", n, card.Value, card.Value)
+	}
+	switch {
+	case strings.HasPrefix(n, "MJD"):
+		return ref.mjd(v)
+	case strings.HasPrefix(n, "JD"):
+		return ref.mjd(v - 2400000.5)
+	case n == "TIMEZERO":
+		// the offset itself, not a time relative to it
+		return ref.toTime(0)
+	}
+	return ref.toTime(v)
+}
+
+// timeRef is the time reference of the columns of a table, as described by
+// the TIMESYS, MJDREF (or MJDREFI/MJDREFF, JDREF, DATEREF), TIMEZERO and
+// TIMEUNIT keywords.
+type timeRef struct {
+	sys  string  // time scale (TIMESYS)
+	refi int64   // integer part of the reference MJD
+	reff float64 // fractional part of the reference MJD
+	zero float64 // time offset (TIMEZERO), in units of unit
+	unit float64 // time unit (TIMEUNIT), in seconds
+	err  error   // invalid keyword, reported when the reference is used
+}
+
+// newTimeRef returns the time reference described by the keywords of hdr.
+func newTimeRef(hdr *Header) *timeRef {
+	ref := &timeRef{sys: "UTC", unit: 1}
+	invalid := func(card *Card) {
+		if ref.err == nil {
+			ref.err = fmt.Errorf("cfitsio: invalid %s value [%v] (%T)", card.Name, card.Value, card.Value)
+		}
+	}
+	float := func(n string) (float64, bool) {
+		card := hdr.Get(n)
+		if card == nil {
+			return 0, false
+		}
+		v, ok := cardFloat(card.Value)
+		if !ok {
+			invalid(card)
+		}
+		return v, ok
+	}
+
+	if card := hdr.Get("TIMESYS"); card != nil {
+		str, ok := card.Value.(string)
+		if !ok {
+			invalid(card)
+		}
+		ref.sys = strings.ToUpper(strings.TrimSpace(str))
+	}
+
+	vi, oki := float("MJDREFI")
+	vf, okf := float("MJDREFF")
+	switch {
+	case oki || okf:
+		ref.setMJD(vi, vf)
+	default:
+		if v, ok := float("MJDREF"); ok {
+			ref.setMJD(v, 0)
+		} else if v, ok := float("JDREF"); ok {
+			ref.setMJD(v-2400000, -0.5)
+		} else if card := hdr.Get("DATEREF"); card != nil {
+			str, _ := card.Value.(string)
+			t, err := ParseTime(str)
+			if err != nil {
+				invalid(card)
+				break
+			}
+			secs := float64(t.Unix()-mjdEpoch.Unix()) + float64(t.Nanosecond())*1e-9
+			ref.setMJD(0, secs/86400)
+		}
+	}
+
+	if v, ok := float("TIMEZERO"); ok {
+		ref.zero = v
+	}
+
+	if card := hdr.Get("TIMEUNIT"); card != nil {
+		str, _ := card.Value.(string)
+		unit, ok := timeUnit(str)
+		if !ok {
+			invalid(card)
+		}
+		ref.unit = unit
+	}
+	return ref
+}
+
+// setMJD sets the reference MJD to i+f, keeping whole days in refi.
+func (ref *timeRef) setMJD(i, f float64) {
+	v := math.Floor(i) + math.Floor(f)
+	ref.refi = int64(v)
+	ref.reff = (i - math.Floor(i)) + (f - math.Floor(f))
+	if ref.reff >= 1 {
+		ref.refi++
+		ref.reff--
+	}
+}
+
+// column returns the time reference of a time column with unit TUNIT:
+// time units override TIMEUNIT.
+func (ref *timeRef) column(tunit string) *timeRef {
+	unit, ok := timeUnit(tunit)
+	if !ok || unit == ref.unit {
+		return ref
+	}
+	o := *ref
+	o.unit = unit
+	return &o
+}
+
+// timeUnit returns the duration in seconds of the time unit str.
+func timeUnit(str string) (float64, bool) {
+	switch strings.TrimSpace(str) {
+	case "", "s":
+		return 1, true
+	case "min":
+		return 60, true
+	case "h":
+		return 3600, true
+	case "d":
+		return 86400, true
+	case "a", "yr":
+		return 365.25 * 86400, true
+	case "cy":
+		return 36525 * 86400, true
+	}
+	return 0, false
+}
+
+// toTime converts v, a time relative to the reference, to a UTC time.Time.
+func (ref *timeRef) toTime(v float64) (time.Time, error) {
+	if ref.err != nil {
+		return time.Time{}, ref.err
+	}
+	return ref.toUTC(mjdTime(ref.refi, ref.reff*86400+(ref.zero+v)*ref.unit))
+}
+
+// fromTime converts t to a time relative to the reference.
+func (ref *timeRef) fromTime(t time.Time) (float64, error) {
+	if ref.err != nil {
+		return 0, ref.err
+	}
+	t, err := ref.fromUTC(t)
+	if err != nil {
+		return 0, err
+	}
+	o := mjdTime(ref.refi, ref.reff*86400)
+	secs := float64(t.Unix()-o.Unix()) + float64(t.Nanosecond()-o.Nanosecond())*1e-9
+	return secs/ref.unit - ref.zero, nil
+}
+
+// mjd converts the MJD v, in the time scale of the reference, to a UTC time.Time.
+func (ref *timeRef) mjd(v float64) (time.Time, error) {
+	if ref.err != nil {
+		return time.Time{}, ref.err
+	}
+	days := math.Floor(v)
+	return ref.toUTC(mjdTime(int64(days), (v-days)*86400))
+}
+
+// parse parses the FITS date string s, in the time scale of the reference,
+// into a UTC time.Time.
+func (ref *timeRef) parse(s string) (time.Time, error) {
+	if ref.err != nil {
+		return time.Time{}, ref.err
+	}
+	t, err := ParseTime(s)
+	if err != nil {
+		return t, err
+	}
+	return ref.toUTC(t)
+}
+
+// format formats t as a FITS date string in the time scale of the reference.
+func (ref *timeRef) format(t time.Time, decimals int) (string, error) {
+	if ref.err != nil {
+		return "", ref.err
+	}
+	t, err := ref.fromUTC(t)
+	if err != nil {
+		return "", err
+	}
+	return FormatTime(t, decimals)
+}
+
+// mjdTime returns the time days (MJD) plus secs seconds, without time scale
+// conversion.
+func mjdTime(days int64, secs float64) time.Time {
+	d := math.Floor(secs / 86400)
+	secs -= d * 86400
+	t := mjdEpoch.AddDate(0, 0, int(days+int64(d)))
+	return t.Add(time.Duration(math.Floor(secs*1e9 + 0.5)))
+}
+
+// toUTC converts t, in the time scale of the reference, to UTC.
+func (ref *timeRef) toUTC(t time.Time) (time.Time, error) {
+	off, err := ref.offset(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	off, _ = ref.offset(t.Add(-off))
+	return t.Add(-off), nil
+}
+
+// fromUTC converts the UTC time t to the time scale of the reference.
+func (ref *timeRef) fromUTC(t time.Time) (time.Time, error) {
+	off, err := ref.offset(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC().Add(off), nil
+}
+
+// offset returns the difference between the time scale of the reference
+// and UTC, at the UTC time t.
+// TDB is approximated by TT (within 2ms.)
+func (ref *timeRef) offset(t time.Time) (time.Duration, error) {
+	switch ref.sys {
+	case "UTC", "UT", "UT1", "GMT", "LOCAL":
+		return 0, nil
+	case "TAI", "IAT":
+		return leapSeconds(t), nil
+	case "TT", "TDT", "ET", "TDB":
+		return leapSeconds(t) + 32184*time.Millisecond, nil
+	case "GPS":
+		return leapSeconds(t) - 19*time.Second, nil
+	}
+	return 0, fmt.Errorf("cfitsio: unsupported time scale (TIMESYS=%q)", ref.sys)
+}
+
+// leapSeconds returns TAI-UTC at the UTC time t.
+// Before 1972, TAI-UTC is approximated by 10s.
+func leapSeconds(t time.Time) time.Duration {
+	n := 10
+	for _, leap := range g_leaps {
+		if t.Before(leap.t) {
+			break
+		}
+		n = leap.n
+	}
+	return time.Duration(n) * time.Second
+}
+
+// g_leaps lists the values of TAI-UTC, and the dates they took effect.
+var g_leaps = func() []struct {
+	t time.Time
+	n int
+} {
+	leaps := []struct {
+		y, m, n int
+	}{
+		{1972, 1, 10}, {1972, 7, 11}, {1973, 1, 12}, {1974, 1, 13},
+		{1975, 1, 14}, {1976, 1, 15}, {1977, 1, 16}, {1978, 1, 17},
+		{1979, 1, 18}, {1980, 1, 19}, {1981, 7, 20}, {1982, 7, 21},
+		{1983, 7, 22}, {1985, 7, 23}, {1988, 1, 24}, {1990, 1, 25},
+		{1991, 1, 26}, {1992, 7, 27}, {1993, 7, 28}, {1994, 7, 29},
+		{1996, 1, 30}, {1997, 7, 31}, {1999, 1, 32}, {2006, 1, 33},
+		{2009, 1, 34}, {2012, 7, 35}, {2015, 7, 36}, {2017, 1, 37},
+	}
+	o := make([]struct {
+		t time.Time
+		n int
+	}, len(leaps))
+	for i, leap := range leaps {
+		o[i].t = time.Date(leap.y, time.Month(leap.m), 1, 0, 0, 0, 0, time.UTC)
+		o[i].n = leap.n
+	}
+	return o
+}()
+
+// timeRef returns the time reference of this Column.
+func (col *Column) timeRef() *timeRef {
+	if col.tref == nil {
+		return &timeRef{sys: "UTC", unit: 1}
+	}
+	return col.tref
+}
+
+// timeWidth returns whether this Column holds times as FITS date strings,
+// and the width of these strings.
+func (col *Column) timeWidth() (int, bool) {
+	if df, err := parseASCIIFormat(col.Format); err == nil {
+		return df.w, df.code == "A"
+	}
+	repeat, code, err := parseTForm(col.Format)
+	if err != nil || code != "A" {
+		return 0, false
+	}
+	return int(repeat), true
+}
+
+// readTime reads the cell at column icol and row irow as a time.Time: either
+// a FITS date string, or a time relative to the time reference of the table.
+func (col *Column) readTime(f *File, icol int, irow int64) (time.Time, error) {
+	if _, ok := col.timeWidth(); ok {
+		var str string
+		err := col.read(f, icol, irow, &str)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return col.timeRef().parse(str)
+	}
+
+	var v float64
+	err := col.read(f, icol, irow, &v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return col.timeRef().toTime(v)
+}
+
+// writeTime writes t at column icol and row irow, as a FITS date string with
+// as many decimals as the column width allows, or as a time relative to the
+// time reference of the table.
+func (col *Column) writeTime(f *File, icol int, irow int64, t time.Time) error {
+	if w, ok := col.timeWidth(); ok {
+		decimals := -1 // "YYYY-MM-DD"
+		switch {
+		case w >= 21: // "YYYY-MM-DDThh:mm:ss.s..."
+			decimals = w - 20
+			if decimals > 9 {
+				decimals = 9
+			}
+		case w >= 19: // "YYYY-MM-DDThh:mm:ss"
+			decimals = 0
+		}
+		str, err := col.timeRef().format(t, decimals)
+		if err != nil {
+			return err
+		}
+		return col.write(f, icol, irow, str)
+	}
+
+	v, err := col.timeRef().fromTime(t)
+	if err != nil {
+		return err
+	}
+	return col.write(f, icol, irow, v)
+}
+
+// EOF
@@ -0,0 +1,127 @@
+//go:build go1.18
+// +build go1.18
+
+package cfitsio
+
+import (
+	"io"
+	"reflect"
+)
+
+// TableReader reads rows of a Table into values of the struct type T.
+//
+// The mapping between the fields of T and the columns of the table follows
+// the same `fits` struct tags than Rows.Scan, but is computed only once,
+// and columns are read in bulk:
+//
+//	r, err := cfitsio.NewTableReader[Row](table)
+//	...
+//	rows := make([]Row, 128)
+//	for {
+//	    n, err := r.Read(rows)
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    ...
+//	}
+type TableReader[T any] struct {
+	table  *Table
+	fields []typedField
+	cur    int64 // index of the next row to read
+}
+
+// NewTableReader returns a new TableReader reading rows of table, starting
+// from the first row.
+func NewTableReader[T any](table *Table) (*TableReader[T], error) {
+	var zero T
+	fields, err := typedFields(table, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &TableReader[T]{table: table, fields: fields}, nil
+}
+
+// SeekRow sets the index of the next row to read.
+func (r *TableReader[T]) SeekRow(irow int64) {
+	r.cur = irow
+}
+
+// Read reads up to len(dst) rows into dst and returns the number of rows
+// read. At the end of the table, Read returns 0, io.EOF.
+func (r *TableReader[T]) Read(dst []T) (int, error) {
+	beg := r.cur
+	end := beg + int64(len(dst))
+	if end > r.table.NumRows() {
+		end = r.table.NumRows()
+	}
+	if beg >= end {
+		if len(dst) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := int(end - beg)
+	err := r.read(beg, end, dst[:n])
+	if err != nil {
+		return 0, err
+	}
+	r.cur = end
+	return n, nil
+}
+
+// ReadRange reads the rows over the range [beg, end).
+// If end > NumRows(), the read stops at NumRows().
+func (r *TableReader[T]) ReadRange(beg, end int64) ([]T, error) {
+	if beg < 0 {
+		beg = 0
+	}
+	if end > r.table.NumRows() {
+		end = r.table.NumRows()
+	}
+	if end < beg {
+		end = beg
+	}
+	rows := make([]T, int(end-beg))
+	err := r.read(beg, end, rows)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *TableReader[T]) read(beg, end int64, dst []T) error {
+	return r.table.readStructs(r.fields, beg, end, reflect.ValueOf(dst))
+}
+
+// TableWriter writes values of the struct type T as rows of a Table.
+//
+// The mapping between the fields of T and the columns of the table follows
+// the same `fits` struct tags than Table.Write, but is computed only once,
+// and columns are written in bulk.
+type TableWriter[T any] struct {
+	table  *Table
+	fields []typedField
+}
+
+// NewTableWriter returns a new TableWriter writing rows into table.
+func NewTableWriter[T any](table *Table) (*TableWriter[T], error) {
+	var zero T
+	fields, err := typedFields(table, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &TableWriter[T]{table: table, fields: fields}, nil
+}
+
+// Write appends rows at the end of the table.
+func (w *TableWriter[T]) Write(rows []T) error {
+	return w.WriteAt(w.table.NumRows(), rows)
+}
+
+// WriteAt writes rows starting at row beg, overwriting existing rows.
+// Rows past the end of the table are appended.
+func (w *TableWriter[T]) WriteAt(beg int64, rows []T) error {
+	return w.table.writeStructs(w.fields, beg, reflect.ValueOf(rows))
+}
+
+// EOF
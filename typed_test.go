@@ -0,0 +1,113 @@
+//go:build go1.18
+// +build go1.18
+
+package cfitsio
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestTableReaderWriter(t *testing.T) {
+	type Row struct {
+		ID  int64      `fits:"id"`
+		X   float64    `fits:"x"`
+		V   [3]float32 `fits:"v"`
+		S   string     `fits:"s,format=8A"`
+		P   *int32     `fits:"p,null=-1"`
+		A   []float64  `fits:"a"`
+		Tmp int        `fits:"-"`
+	}
+
+	nrows := 10
+	data := make([]Row, nrows)
+	for i := range data {
+		data[i] = Row{
+			ID: int64(i),
+			X:  float64(i) + 0.5,
+			V:  [3]float32{float32(i), float32(2 * i), float32(3 * i)},
+			S:  string(rune('a' + i)),
+			A:  make([]float64, i%3),
+		}
+		if i%2 == 0 {
+			p := int32(-i)
+			data[i].P = &p
+		}
+		for j := range data[i].A {
+			data[i].A[j] = float64(i * j)
+		}
+	}
+
+	f, done := newTestFile(t)
+	defer done()
+
+	tbl, err := NewTableFromStruct(f, "test", Row{}, BINARY_TBL)
+	if err != nil {
+		t.Fatalf("error creating new table: %v", err)
+	}
+	defer tbl.Close()
+
+	w, err := NewTableWriter[Row](tbl)
+	if err != nil {
+		t.Fatalf("error creating table writer: %v", err)
+	}
+	err = w.Write(data[:6])
+	if err != nil {
+		t.Fatalf("error writing rows: %v", err)
+	}
+	err = w.Write(data[6:])
+	if err != nil {
+		t.Fatalf("error writing rows: %v", err)
+	}
+	if tbl.NumRows() != int64(nrows) {
+		t.Fatalf("expected %d rows. got %d", nrows, tbl.NumRows())
+	}
+
+	r, err := NewTableReader[Row](tbl)
+	if err != nil {
+		t.Fatalf("error creating table reader: %v", err)
+	}
+	got := make([]Row, 0, nrows)
+	buf := make([]Row, 4)
+	for {
+		n, err := r.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading rows: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data, got)
+	}
+
+	rows, err := r.ReadRange(3, 5)
+	if err != nil {
+		t.Fatalf("error reading rows: %v", err)
+	}
+	if !reflect.DeepEqual(rows, data[3:5]) {
+		t.Fatalf("expected\nref=%+v\ngot=%+v", data[3:5], rows)
+	}
+
+	type Bad struct {
+		V float32 `fits:"v"`
+	}
+	bw, err := NewTableWriter[Bad](tbl)
+	if err != nil {
+		t.Fatalf("error creating table writer: %v", err)
+	}
+	err = bw.Write([]Bad{{1}})
+	if err == nil {
+		t.Fatalf("expected an error writing a scalar into a vector column")
+	}
+
+	_, err = NewTableReader[int](tbl)
+	if err == nil {
+		t.Fatalf("expected an error for a non-struct row type")
+	}
+}
+
+// EOF
@@ -5,6 +5,7 @@ package cfitsio
 import "C"
 import (
 	"reflect"
+	"strconv"
 	"strings"
 	"unsafe"
 )
@@ -43,8 +44,12 @@ func gotype2FITS(v interface{}, hdu HDUType) string {
 		hdr = "Q"
 		rt = rt.Elem()
 	case reflect.Array:
-		hdr = "Q"
-		rt = rt.Elem()
+		if hdu != BINARY_TBL {
+			return ""
+		}
+		dims, elem := arrayShape(rt)
+		hdr = strconv.FormatInt(nelements(dims), 10)
+		rt = elem
 	default:
 		// no-op
 	}
@@ -63,7 +68,7 @@ func gotype2FITS(v interface{}, hdu HDUType) string {
 var g_gotype2FITS = map[reflect.Kind]map[HDUType]string{
 
 	reflect.Bool: {
-		ASCII_TBL:  "",
+		ASCII_TBL:  "A1", // stored as "T" or "F"
 		BINARY_TBL: "L",
 	},
 
@@ -94,7 +99,7 @@ var g_gotype2FITS = map[reflect.Kind]map[HDUType]string{
 
 	reflect.Uint: {
 		ASCII_TBL:  "I21",
-		BINARY_TBL: "V",
+		BINARY_TBL: "W",
 	},
 
 	reflect.Uint8: {
@@ -114,7 +119,7 @@ var g_gotype2FITS = map[reflect.Kind]map[HDUType]string{
 
 	reflect.Uint64: {
 		ASCII_TBL:  "I21",
-		BINARY_TBL: "V",
+		BINARY_TBL: "W",
 	},
 
 	reflect.Uintptr: {
@@ -0,0 +1,715 @@
+package cfitsio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WCS is a World Coordinate System, as described by the WCS keywords of a
+// FITS image (or of an image column in a table).
+//
+// The linear transformation is read from the CDi_j keywords or, if absent,
+// from the PCi_j and CDELTi keywords (or the legacy CROTAi keyword).
+// Celestial axes ("RA--"/"DEC-", "xLON"/"xLAT" or "xyLN"/"xyLT") are
+// supported with the TAN, SIN, ARC, CAR, AIT and ZEA projections, plus the
+// TAN-SIP distortion convention for images.
+// Other axes are linear.
+//
+// Pixel coordinates are 0-based: the centre of the first pixel is at 0
+// (FITS pixel 1), as for the other pixel indices of this package.
+// Celestial world coordinates are in degrees.
+type WCS struct {
+	Name    string      // WCS name, corresponding to ``WCSNAME`` keyword
+	Alt     string      // alternate description letter ("" for the primary one)
+	CType   []string    // axes types, corresponding to ``CTYPEi`` keywords
+	CUnit   []string    // axes units, corresponding to ``CUNITi`` keywords
+	CRVal   []float64   // world coordinates of the reference point (``CRVALi``)
+	CRPix   []float64   // 1-based pixel coordinates of the reference point (``CRPIXi``)
+	CD      [][]float64 // linear transformation matrix (``CDi_j`` or ``CDELTi*PCi_j``)
+	LonPole float64     // native longitude of the celestial pole (``LONPOLE``)
+	LatPole float64     // native latitude of the celestial pole (``LATPOLE``)
+
+	icd [][]float64 // inverse of CD
+
+	lng  int    // index of the celestial longitude axis (-1 if none)
+	lat  int    // index of the celestial latitude axis (-1 if none)
+	proj string // projection code of the celestial axes
+
+	phi0   float64 // native longitude of the reference point
+	theta0 float64 // native latitude of the reference point
+	alphaP float64 // celestial longitude of the native pole
+	deltaP float64 // celestial latitude of the native pole
+
+	sip *wcsSIP
+}
+
+// wcsSIP holds the coefficients of a SIP distortion.
+// Coefficients are indexed as [p][q] for the u^p*v^q term.
+type wcsSIP struct {
+	a, b   [][]float64 // forward distortion
+	ap, bp [][]float64 // inverse distortion (optional)
+}
+
+// wcsKeys names the WCS keywords of an image or of an image column.
+type wcsKeys struct {
+	axis   func(kw string, i int) string    // CTYPE, CUNIT, CRVAL, CRPIX, CDELT, CROTA
+	matrix func(kw string, i, j int) string // PC, CD
+	global func(kw string) string           // WCSAXES, WCSNAME, LONPOLE, LATPOLE
+}
+
+// imageWCSKeys returns the WCS keywords naming for images.
+func imageWCSKeys(alt string) wcsKeys {
+	return wcsKeys{
+		axis: func(kw string, i int) string {
+			return kw + strconv.Itoa(i) + alt
+		},
+		matrix: func(kw string, i, j int) string {
+			return kw + strconv.Itoa(i) + "_" + strconv.Itoa(j) + alt
+		},
+		global: func(kw string) string {
+			return kw + alt
+		},
+	}
+}
+
+// columnWCSKeys returns the WCS keywords naming for the image column n (1-based).
+func columnWCSKeys(n int, alt string) wcsKeys {
+	col := strconv.Itoa(n)
+	short := map[string]string{
+		"CTYPE":   "CTYP",
+		"CUNIT":   "CUNI",
+		"CRVAL":   "CRVL",
+		"CRPIX":   "CRPX",
+		"CDELT":   "CDLT",
+		"CROTA":   "CROT",
+		"WCSAXES": "WCAX",
+		"WCSNAME": "WCSN",
+		"LONPOLE": "LONP",
+		"LATPOLE": "LATP",
+	}
+	return wcsKeys{
+		axis: func(kw string, i int) string {
+			return strconv.Itoa(i) + short[kw] + col + alt
+		},
+		matrix: func(kw string, i, j int) string {
+			return strconv.Itoa(i) + strconv.Itoa(j) + kw + col + alt
+		},
+		global: func(kw string) string {
+			return short[kw] + col + alt
+		},
+	}
+}
+
+// WCS returns the world coordinate system described by the header of this image.
+// alt selects an alternate description ("A" to "Z"), or the primary one if empty.
+func (hdu *ImageHDU) WCS(alt string) (*WCS, error) {
+	return newWCS(&hdu.header, len(hdu.header.Axes()), imageWCSKeys(alt), alt, true)
+}
+
+// PixelToWorld converts the (0-based) pixel coordinates of this image into
+// world coordinates, using the primary WCS of its header.
+func (hdu *ImageHDU) PixelToWorld(pixel []float64) ([]float64, error) {
+	w, err := hdu.WCS("")
+	if err != nil {
+		return nil, err
+	}
+	return w.PixelToWorld(pixel)
+}
+
+// WorldToPixel converts world coordinates into (0-based) pixel coordinates
+// of this image, using the primary WCS of its header.
+func (hdu *ImageHDU) WorldToPixel(world []float64) ([]float64, error) {
+	w, err := hdu.WCS("")
+	if err != nil {
+		return nil, err
+	}
+	return w.WorldToPixel(world)
+}
+
+// ColumnWCS returns the world coordinate system of the image column named n,
+// as described by the image-column WCS keywords (iCTYPn, iCRVLn, iCRPXn,
+// iCDLTn, ijPCn, ijCDn, ...) of this table.
+// alt selects an alternate description ("A" to "Z"), or the primary one if empty.
+func (hdu *Table) ColumnWCS(n string, alt string) (*WCS, error) {
+	icol := hdu.Index(n)
+	if icol < 0 {
+		return nil, fmt.Errorf("cfitsio: no column named [%s]", n)
+	}
+	keys := columnWCSKeys(icol+1, alt)
+	return newWCS(&hdu.header, hdu.columnAxes(icol, keys), keys, alt, false)
+}
+
+// columnAxes returns the number of axes of the image column icol (0-based),
+// in the absence of a WCAXn keyword: the number of TDIM dimensions, or else
+// the highest axis number of its WCS keywords, or 1 for vector columns.
+func (hdu *Table) columnAxes(icol int, keys wcsKeys) int {
+	col := hdu.Col(icol)
+	if len(col.Dim) > 0 {
+		return len(col.Dim)
+	}
+	naxis := 0
+	for i := 1; i <= 9; i++ {
+		for _, kw := range []string{"CTYPE", "CUNIT", "CRVAL", "CRPIX", "CDELT"} {
+			if hdu.header.Get(keys.axis(kw, i)) != nil {
+				naxis = i
+			}
+		}
+	}
+	if naxis > 0 {
+		return naxis
+	}
+	if repeat, _, err := parseTForm(col.Format); err == nil && repeat > 1 {
+		return 1
+	}
+	return 0
+}
+
+// wcsParser reads WCS keywords from a Header
+type wcsParser struct {
+	hdr   *Header
+	found bool // whether any WCS keyword was found
+}
+
+func (p *wcsParser) float(n string, def float64) (float64, bool) {
+	card := p.hdr.Get(n)
+	if card == nil {
+		return def, false
+	}
+	v, ok := cardFloat(card.Value)
+	if !ok {
+		return def, false
+	}
+	p.found = true
+	return v, true
+}
+
+func (p *wcsParser) str(n string, def string) string {
+	card := p.hdr.Get(n)
+	if card == nil {
+		return def
+	}
+	v, ok := card.Value.(string)
+	if !ok {
+		return def
+	}
+	p.found = true
+	return strings.TrimSpace(v)
+}
+
+// newWCS parses the WCS described by hdr, for naxis axes, with keywords named by keys.
+func newWCS(hdr *Header, naxis int, keys wcsKeys, alt string, sip bool) (*WCS, error) {
+	var err error
+	if len(alt) > 1 || (alt != "" && (alt[0] < 'A' || alt[0] > 'Z')) {
+		return nil, fmt.Errorf("cfitsio: invalid alternate WCS [%s]", alt)
+	}
+
+	p := wcsParser{hdr: hdr}
+	if v, ok := p.float(keys.global("WCSAXES"), 0); ok {
+		naxis = int(v)
+	}
+	if naxis <= 0 {
+		return nil, fmt.Errorf("cfitsio: no WCS axis")
+	}
+
+	w := &WCS{
+		Name:  p.str(keys.global("WCSNAME"), ""),
+		Alt:   alt,
+		CType: make([]string, naxis),
+		CUnit: make([]string, naxis),
+		CRVal: make([]float64, naxis),
+		CRPix: make([]float64, naxis),
+		CD:    make([][]float64, naxis),
+		lng:   -1,
+		lat:   -1,
+	}
+
+	cdelt := make([]float64, naxis)
+	for i := 0; i < naxis; i++ {
+		w.CType[i] = p.str(keys.axis("CTYPE", i+1), "")
+		w.CUnit[i] = p.str(keys.axis("CUNIT", i+1), "")
+		w.CRVal[i], _ = p.float(keys.axis("CRVAL", i+1), 0)
+		w.CRPix[i], _ = p.float(keys.axis("CRPIX", i+1), 0)
+		cdelt[i], _ = p.float(keys.axis("CDELT", i+1), 1)
+		w.CD[i] = make([]float64, naxis)
+	}
+
+	// linear transformation: CDi_j, then PCi_j, then CROTAi
+	hasCD := false
+	hasPC := false
+	for i := 0; i < naxis; i++ {
+		for j := 0; j < naxis; j++ {
+			if _, ok := p.float(keys.matrix("CD", i+1, j+1), 0); ok {
+				hasCD = true
+			}
+			if _, ok := p.float(keys.matrix("PC", i+1, j+1), 0); ok {
+				hasPC = true
+			}
+		}
+	}
+	switch {
+	case hasCD:
+		for i := 0; i < naxis; i++ {
+			for j := 0; j < naxis; j++ {
+				w.CD[i][j], _ = p.float(keys.matrix("CD", i+1, j+1), 0)
+			}
+		}
+	default:
+		for i := 0; i < naxis; i++ {
+			for j := 0; j < naxis; j++ {
+				def := 0.0
+				if i == j {
+					def = 1
+				}
+				pc, _ := p.float(keys.matrix("PC", i+1, j+1), def)
+				w.CD[i][j] = cdelt[i] * pc
+			}
+		}
+	}
+
+	if !p.found {
+		return nil, fmt.Errorf("cfitsio: no WCS%s keywords in header", alt)
+	}
+
+	for i, ctype := range w.CType {
+		if len(ctype) < 8 || ctype[4] != '-' {
+			continue
+		}
+		switch prefix := ctype[:4]; {
+		case prefix == "RA--" || prefix[1:] == "LON" || prefix[2:] == "LN":
+			if w.lng >= 0 {
+				return nil, fmt.Errorf("cfitsio: duplicate WCS longitude axis (%s)", ctype)
+			}
+			w.lng = i
+		case prefix == "DEC-" || prefix[1:] == "LAT" || prefix[2:] == "LT":
+			if w.lat >= 0 {
+				return nil, fmt.Errorf("cfitsio: duplicate WCS latitude axis (%s)", ctype)
+			}
+			w.lat = i
+		}
+	}
+
+	if (w.lng < 0) != (w.lat < 0) {
+		return nil, fmt.Errorf("cfitsio: incomplete celestial WCS axes (%v)", w.CType)
+	}
+
+	if w.lng >= 0 {
+		proj := w.CType[w.lng][5:8]
+		if w.CType[w.lat][5:8] != proj {
+			return nil, fmt.Errorf(
+				"cfitsio: WCS projections differ (%s != %s)",
+				w.CType[w.lng], w.CType[w.lat],
+			)
+		}
+		w.proj = proj
+
+		// legacy rotation of the celestial axes
+		if !hasCD && !hasPC {
+			if rho, ok := p.float(keys.axis("CROTA", w.lat+1), 0); ok && rho != 0 {
+				cos := math.Cos(rho * math.Pi / 180)
+				sin := math.Sin(rho * math.Pi / 180)
+				i, j := w.lng, w.lat
+				w.CD[i][i] = cdelt[i] * cos
+				w.CD[i][j] = -cdelt[j] * sin
+				w.CD[j][i] = cdelt[i] * sin
+				w.CD[j][j] = cdelt[j] * cos
+			}
+		}
+
+		err = w.initCelestial(&p, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		if sip && naxis >= 2 && strings.HasSuffix(w.CType[0], "-SIP") && strings.HasSuffix(w.CType[1], "-SIP") {
+			w.sip, err = newSIP(&p)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.icd, err = invertMatrix(w.CD)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, err
+}
+
+// initCelestial computes the celestial coordinates of the native pole.
+func (w *WCS) initCelestial(p *wcsParser, keys wcsKeys) error {
+	switch w.proj {
+	case "TAN", "SIN", "ARC", "ZEA":
+		w.phi0, w.theta0 = 0, 90
+	case "CAR", "AIT":
+		w.phi0, w.theta0 = 0, 0
+	default:
+		return fmt.Errorf("cfitsio: unsupported WCS projection [%s]", w.proj)
+	}
+
+	alpha0 := w.CRVal[w.lng]
+	delta0 := w.CRVal[w.lat]
+
+	def := w.phi0 + 180
+	if delta0 >= w.theta0 {
+		def = w.phi0
+	}
+	w.LonPole, _ = p.float(keys.global("LONPOLE"), def)
+	w.LatPole, _ = p.float(keys.global("LATPOLE"), 90)
+
+	phip := w.LonPole
+	switch {
+	case w.theta0 == 90:
+		w.deltaP = delta0
+	default:
+		a := atan2d(sind(w.theta0), cosd(w.theta0)*cosd(phip-w.phi0))
+		c := cosd(w.theta0) * sind(phip-w.phi0)
+		r := sind(delta0) / math.Sqrt(1-c*c)
+		if math.IsNaN(r) || math.Abs(r) > 1+1e-12 {
+			return fmt.Errorf("cfitsio: invalid WCS celestial pole")
+		}
+		b := acosd(clamp(r))
+		found := false
+		for _, v := range []float64{a + b, a - b} {
+			v = normAngle(v)
+			if math.Abs(v) > 90+1e-12 {
+				continue
+			}
+			if !found || math.Abs(v-w.LatPole) < math.Abs(w.deltaP-w.LatPole) {
+				w.deltaP = v
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("cfitsio: invalid WCS celestial pole")
+		}
+	}
+
+	switch {
+	case w.deltaP >= 90-1e-12:
+		w.alphaP = alpha0 + phip - w.phi0 - 180
+	case w.deltaP <= -90+1e-12:
+		w.alphaP = alpha0 - phip + w.phi0
+	default:
+		w.alphaP = alpha0 - atan2d(
+			sind(phip-w.phi0)*cosd(w.theta0),
+			(sind(w.theta0)-sind(w.deltaP)*sind(delta0))/cosd(w.deltaP),
+		)
+	}
+	return nil
+}
+
+// newSIP reads the coefficients of a SIP distortion.
+func newSIP(p *wcsParser) (*wcsSIP, error) {
+	var err error
+	sip := &wcsSIP{}
+	for _, poly := range []struct {
+		name     string
+		coeffs   *[][]float64
+		optional bool
+	}{
+		{"A", &sip.a, false},
+		{"B", &sip.b, false},
+		{"AP", &sip.ap, true},
+		{"BP", &sip.bp, true},
+	} {
+		order, ok := p.float(poly.name+"_ORDER", 0)
+		if !ok {
+			if poly.optional {
+				continue
+			}
+			return nil, fmt.Errorf("cfitsio: missing SIP keyword %s_ORDER", poly.name)
+		}
+		n := int(order)
+		coeffs := make([][]float64, n+1)
+		for i := range coeffs {
+			coeffs[i] = make([]float64, n+1-i)
+			for j := range coeffs[i] {
+				coeffs[i][j], _ = p.float(fmt.Sprintf("%s_%d_%d", poly.name, i, j), 0)
+			}
+		}
+		*poly.coeffs = coeffs
+	}
+	if (sip.ap == nil) != (sip.bp == nil) {
+		return nil, fmt.Errorf("cfitsio: incomplete SIP inverse distortion")
+	}
+	return sip, err
+}
+
+// PixelToWorld converts (0-based) pixel coordinates into world coordinates.
+func (w *WCS) PixelToWorld(pixel []float64) ([]float64, error) {
+	n := len(w.CRVal)
+	if len(pixel) != n {
+		return nil, fmt.Errorf("cfitsio: invalid number of pixel coordinates (%d != %d)", len(pixel), n)
+	}
+
+	d := make([]float64, n)
+	for i := range d {
+		d[i] = pixel[i] + 1 - w.CRPix[i]
+	}
+	if w.sip != nil {
+		u, v := d[0], d[1]
+		d[0] = u + sipPoly(w.sip.a, u, v)
+		d[1] = v + sipPoly(w.sip.b, u, v)
+	}
+
+	x := mulMatrix(w.CD, d)
+	world := make([]float64, n)
+	for i := range world {
+		world[i] = w.CRVal[i] + x[i]
+	}
+
+	if w.lng >= 0 {
+		phi, theta, err := projToNative(w.proj, x[w.lng], x[w.lat])
+		if err != nil {
+			return nil, err
+		}
+		world[w.lng], world[w.lat] = w.toCelestial(phi, theta)
+	}
+	return world, nil
+}
+
+// WorldToPixel converts world coordinates into (0-based) pixel coordinates.
+func (w *WCS) WorldToPixel(world []float64) ([]float64, error) {
+	n := len(w.CRVal)
+	if len(world) != n {
+		return nil, fmt.Errorf("cfitsio: invalid number of world coordinates (%d != %d)", len(world), n)
+	}
+
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = world[i] - w.CRVal[i]
+	}
+
+	if w.lng >= 0 {
+		phi, theta := w.toNative(world[w.lng], world[w.lat])
+		xx, yy, err := projFromNative(w.proj, phi, theta)
+		if err != nil {
+			return nil, err
+		}
+		x[w.lng], x[w.lat] = xx, yy
+	}
+
+	d := mulMatrix(w.icd, x)
+	if w.sip != nil {
+		uu, vv := d[0], d[1]
+		switch {
+		case w.sip.ap != nil:
+			d[0] = uu + sipPoly(w.sip.ap, uu, vv)
+			d[1] = vv + sipPoly(w.sip.bp, uu, vv)
+		default:
+			// no inverse coefficients: solve u+f(u,v) = uu, v+g(u,v) = vv
+			u, v := uu, vv
+			for iter := 0; iter < 50; iter++ {
+				du := uu - sipPoly(w.sip.a, u, v) - u
+				dv := vv - sipPoly(w.sip.b, u, v) - v
+				u += du
+				v += dv
+				if math.Abs(du) < 1e-12 && math.Abs(dv) < 1e-12 {
+					break
+				}
+			}
+			d[0], d[1] = u, v
+		}
+	}
+
+	pixel := make([]float64, n)
+	for i := range pixel {
+		pixel[i] = d[i] + w.CRPix[i] - 1
+	}
+	return pixel, nil
+}
+
+// toCelestial converts native spherical coordinates into celestial ones.
+func (w *WCS) toCelestial(phi, theta float64) (alpha, delta float64) {
+	dphi := phi - w.LonPole
+	alpha = w.alphaP + atan2d(
+		-cosd(theta)*sind(dphi),
+		sind(theta)*cosd(w.deltaP)-cosd(theta)*sind(w.deltaP)*cosd(dphi),
+	)
+	delta = asind(clamp(sind(theta)*sind(w.deltaP) + cosd(theta)*cosd(w.deltaP)*cosd(dphi)))
+
+	alpha = math.Mod(alpha, 360)
+	if alpha < 0 {
+		alpha += 360
+	}
+	if alpha >= 360 {
+		alpha -= 360
+	}
+	return alpha, delta
+}
+
+// toNative converts celestial coordinates into native spherical ones.
+func (w *WCS) toNative(alpha, delta float64) (phi, theta float64) {
+	dalpha := alpha - w.alphaP
+	phi = w.LonPole + atan2d(
+		-cosd(delta)*sind(dalpha),
+		sind(delta)*cosd(w.deltaP)-cosd(delta)*sind(w.deltaP)*cosd(dalpha),
+	)
+	theta = asind(clamp(sind(delta)*sind(w.deltaP) + cosd(delta)*cosd(w.deltaP)*cosd(dalpha)))
+	return normAngle(phi), theta
+}
+
+// projToNative converts projection plane coordinates (x,y) into native
+// spherical coordinates (phi,theta) for the projection proj.
+func projToNative(proj string, x, y float64) (phi, theta float64, err error) {
+	const r0 = 180 / math.Pi
+	r := math.Hypot(x, y)
+	if r != 0 {
+		phi = atan2d(x, -y)
+	}
+	switch proj {
+	case "TAN":
+		theta = atan2d(r0, r)
+	case "SIN":
+		if r > r0 {
+			return 0, 0, fmt.Errorf("cfitsio: (%v,%v) outside of SIN projection", x, y)
+		}
+		theta = acosd(r / r0)
+	case "ARC":
+		theta = 90 - r
+	case "ZEA":
+		if r > 2*r0 {
+			return 0, 0, fmt.Errorf("cfitsio: (%v,%v) outside of ZEA projection", x, y)
+		}
+		theta = 90 - 2*asind(r/(2*r0))
+	case "CAR":
+		phi, theta = x, y
+	case "AIT":
+		z2 := 1 - (x/(4*r0))*(x/(4*r0)) - (y/(2*r0))*(y/(2*r0))
+		if z2 < 0.5 {
+			return 0, 0, fmt.Errorf("cfitsio: (%v,%v) outside of AIT projection", x, y)
+		}
+		z := math.Sqrt(z2)
+		phi = 2 * atan2d(z*x/(2*r0), 2*z2-1)
+		theta = asind(clamp(y * z / r0))
+	default:
+		return 0, 0, fmt.Errorf("cfitsio: unsupported WCS projection [%s]", proj)
+	}
+	return phi, theta, err
+}
+
+// projFromNative converts native spherical coordinates (phi,theta) into
+// projection plane coordinates (x,y) for the projection proj.
+func projFromNative(proj string, phi, theta float64) (x, y float64, err error) {
+	const r0 = 180 / math.Pi
+	var r float64
+	switch proj {
+	case "TAN":
+		if theta <= 0 {
+			return 0, 0, fmt.Errorf("cfitsio: (%v,%v) outside of TAN projection", phi, theta)
+		}
+		r = r0 * cosd(theta) / sind(theta)
+	case "SIN":
+		if theta < 0 {
+			return 0, 0, fmt.Errorf("cfitsio: (%v,%v) outside of SIN projection", phi, theta)
+		}
+		r = r0 * cosd(theta)
+	case "ARC":
+		r = 90 - theta
+	case "ZEA":
+		r = r0 * math.Sqrt(2*(1-sind(theta)))
+	case "CAR":
+		return phi, theta, err
+	case "AIT":
+		g := r0 * math.Sqrt(2/(1+cosd(theta)*cosd(phi/2)))
+		return 2 * g * cosd(theta) * sind(phi/2), g * sind(theta), err
+	default:
+		return 0, 0, fmt.Errorf("cfitsio: unsupported WCS projection [%s]", proj)
+	}
+	return r * sind(phi), -r * cosd(phi), err
+}
+
+// sipPoly evaluates the SIP polynomial with coefficients c at (u,v).
+func sipPoly(c [][]float64, u, v float64) float64 {
+	sum := 0.0
+	for p := range c {
+		for q := range c[p] {
+			if c[p][q] != 0 {
+				sum += c[p][q] * math.Pow(u, float64(p)) * math.Pow(v, float64(q))
+			}
+		}
+	}
+	return sum
+}
+
+// mulMatrix returns m*v
+func mulMatrix(m [][]float64, v []float64) []float64 {
+	o := make([]float64, len(m))
+	for i := range m {
+		for j := range m[i] {
+			o[i] += m[i][j] * v[j]
+		}
+	}
+	return o
+}
+
+// invertMatrix returns the inverse of the square matrix m (Gauss-Jordan elimination).
+func invertMatrix(m [][]float64) ([][]float64, error) {
+	n := len(m)
+	a := make([][]float64, n)
+	inv := make([][]float64, n)
+	for i := range m {
+		a[i] = append([]float64(nil), m[i]...)
+		inv[i] = make([]float64, n)
+		inv[i][i] = 1
+	}
+	for col := 0; col < n; col++ {
+		piv := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(a[i][col]) > math.Abs(a[piv][col]) {
+				piv = i
+			}
+		}
+		if a[piv][col] == 0 {
+			return nil, fmt.Errorf("cfitsio: singular WCS matrix")
+		}
+		a[col], a[piv] = a[piv], a[col]
+		inv[col], inv[piv] = inv[piv], inv[col]
+		scale := a[col][col]
+		for j := 0; j < n; j++ {
+			a[col][j] /= scale
+			inv[col][j] /= scale
+		}
+		for i := 0; i < n; i++ {
+			if i == col || a[i][col] == 0 {
+				continue
+			}
+			f := a[i][col]
+			for j := 0; j < n; j++ {
+				a[i][j] -= f * a[col][j]
+				inv[i][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, nil
+}
+
+// trigonometric functions in degrees
+
+func sind(x float64) float64      { return math.Sin(x * math.Pi / 180) }
+func cosd(x float64) float64      { return math.Cos(x * math.Pi / 180) }
+func asind(x float64) float64     { return math.Asin(x) * 180 / math.Pi }
+func acosd(x float64) float64     { return math.Acos(x) * 180 / math.Pi }
+func atan2d(y, x float64) float64 { return math.Atan2(y, x) * 180 / math.Pi }
+
+// clamp restricts x to [-1,1] (to protect against rounding errors)
+func clamp(x float64) float64 {
+	return math.Max(-1, math.Min(1, x))
+}
+
+// normAngle returns the angle x (in degrees) normalized to ]-180,180]
+func normAngle(x float64) float64 {
+	x = math.Mod(x, 360)
+	switch {
+	case x > 180:
+		x -= 360
+	case x <= -180:
+		x += 360
+	}
+	return x
+}
+
+// EOF
@@ -0,0 +1,343 @@
+package cfitsio
+
+import (
+	"math"
+	"testing"
+)
+
+func wcsClose(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWCSProjections(t *testing.T) {
+	for _, proj := range []string{"TAN", "SIN", "ARC", "ZEA", "CAR", "AIT"} {
+		for _, dec := range []float64{-30, 0, 45} {
+			cards := []Card{
+				{"CTYPE1", "RA---" + proj, ""},
+				{"CTYPE2", "DEC--" + proj, ""},
+				{"CRVAL1", 30.0, ""},
+				{"CRVAL2", dec, ""},
+				{"CRPIX1", 50.5, ""},
+				{"CRPIX2", 40.5, ""},
+				{"CDELT1", -0.01, ""},
+				{"CDELT2", 0.01, ""},
+				{"PC1_1", 0.9, ""},
+				{"PC1_2", -0.1, ""},
+				{"PC2_1", 0.1, ""},
+				{"PC2_2", 0.9, ""},
+			}
+			hdu := &ImageHDU{header: NewHeader(cards, IMAGE_HDU, -64, []int64{100, 80})}
+			w, err := hdu.WCS("")
+			if err != nil {
+				t.Fatalf("%s: error parsing WCS: %v", proj, err)
+			}
+
+			world, err := w.PixelToWorld([]float64{49.5, 39.5})
+			if err != nil {
+				t.Fatalf("%s: error converting pixel: %v", proj, err)
+			}
+			if !wcsClose(world, []float64{30, dec}, 1e-9) {
+				t.Fatalf("%s: expected reference point at %v. got %v", proj, w.CRVal, world)
+			}
+
+			for _, pixel := range [][]float64{
+				{0, 0},
+				{99, 79},
+				{10, 70},
+				{75.25, 3.5},
+			} {
+				world, err := hdu.PixelToWorld(pixel)
+				if err != nil {
+					t.Fatalf("%s: error converting pixel %v: %v", proj, pixel, err)
+				}
+				got, err := hdu.WorldToPixel(world)
+				if err != nil {
+					t.Fatalf("%s: error converting world %v: %v", proj, world, err)
+				}
+				if !wcsClose(got, pixel, 1e-6) {
+					t.Fatalf("%s (dec=%v): round-trip failed. pixel=%v world=%v got=%v",
+						proj, dec, pixel, world, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWCSValues(t *testing.T) {
+	for _, table := range []struct {
+		proj  string
+		pixel []float64
+		world []float64
+	}{
+		// gnomonic: x = tan(alpha)
+		{"TAN", []float64{1, 0}, []float64{math.Atan(math.Pi/180) * 180 / math.Pi, 0}},
+		// orthographic: x = sin(alpha)
+		{"SIN", []float64{1, 0}, []float64{math.Asin(math.Pi/180) * 180 / math.Pi, 0}},
+		// zenithal equidistant: distances are preserved
+		{"ARC", []float64{0, 10}, []float64{0, 10}},
+		// plate carree: linear
+		{"CAR", []float64{10, 5}, []float64{10, 5}},
+	} {
+		cards := []Card{
+			{"CTYPE1", "RA---" + table.proj, ""},
+			{"CTYPE2", "DEC--" + table.proj, ""},
+			{"CRVAL1", 0.0, ""},
+			{"CRVAL2", 0.0, ""},
+			{"CRPIX1", 1.0, ""},
+			{"CRPIX2", 1.0, ""},
+			{"CDELT1", 1.0, ""},
+			{"CDELT2", 1.0, ""},
+		}
+		hdu := &ImageHDU{header: NewHeader(cards, IMAGE_HDU, -64, []int64{100, 100})}
+		world, err := hdu.PixelToWorld(table.pixel)
+		if err != nil {
+			t.Fatalf("%s: error converting pixel: %v", table.proj, err)
+		}
+		if !wcsClose(world, table.world, 1e-9) {
+			t.Fatalf("%s: expected world=%v. got %v", table.proj, table.world, world)
+		}
+	}
+}
+
+func TestWCSSIP(t *testing.T) {
+	cards := []Card{
+		{"CTYPE1", "RA---TAN-SIP", ""},
+		{"CTYPE2", "DEC--TAN-SIP", ""},
+		{"CRVAL1", 150.0, ""},
+		{"CRVAL2", 2.0, ""},
+		{"CRPIX1", 101.0, ""},
+		{"CRPIX2", 101.0, ""},
+		{"CD1_1", -1e-4, ""},
+		{"CD1_2", 0.0, ""},
+		{"CD2_1", 0.0, ""},
+		{"CD2_2", 1e-4, ""},
+		{"A_ORDER", int64(2), ""},
+		{"A_2_0", 1e-5, ""},
+		{"B_ORDER", int64(2), ""},
+		{"B_0_2", -2e-5, ""},
+	}
+	hdu := &ImageHDU{header: NewHeader(cards, IMAGE_HDU, -64, []int64{200, 200})}
+	w, err := hdu.WCS("")
+	if err != nil {
+		t.Fatalf("error parsing WCS: %v", err)
+	}
+	if w.sip == nil {
+		t.Fatalf("expected a SIP distortion")
+	}
+
+	// distorted pixel offset: u=100 -> u+A_2_0*u^2 = 100.1
+	world, err := w.PixelToWorld([]float64{200, 100})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	nosip := *w
+	nosip.sip = nil
+	want, err := nosip.PixelToWorld([]float64{200.1, 100})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	if !wcsClose(world, want, 1e-12) {
+		t.Fatalf("expected distorted world=%v. got %v", want, world)
+	}
+
+	for _, pixel := range [][]float64{{0, 0}, {199, 199}, {20, 150}} {
+		world, err := w.PixelToWorld(pixel)
+		if err != nil {
+			t.Fatalf("error converting pixel %v: %v", pixel, err)
+		}
+		got, err := w.WorldToPixel(world)
+		if err != nil {
+			t.Fatalf("error converting world %v: %v", world, err)
+		}
+		if !wcsClose(got, pixel, 1e-6) {
+			t.Fatalf("round-trip failed. pixel=%v world=%v got=%v", pixel, world, got)
+		}
+	}
+}
+
+func TestWCSAlternate(t *testing.T) {
+	cards := []Card{
+		{"CTYPE1", "RA---TAN", ""},
+		{"CTYPE2", "DEC--TAN", ""},
+		{"CRVAL1", 10.0, ""},
+		{"CRVAL2", 20.0, ""},
+		{"CRPIX1", 5.0, ""},
+		{"CRPIX2", 5.0, ""},
+		{"CDELT1", -0.1, ""},
+		{"CDELT2", 0.1, ""},
+		{"CROTA2", 30.0, ""},
+		{"WCSNAMEA", "DETECTOR", ""},
+		{"CTYPE1A", "DETX", ""},
+		{"CTYPE2A", "DETY", ""},
+		{"CUNIT1A", "mm", ""},
+		{"CRVAL1A", 100.0, ""},
+		{"CRVAL2A", 200.0, ""},
+		{"CRPIX1A", 1.0, ""},
+		{"CRPIX2A", 1.0, ""},
+		{"CDELT1A", 0.5, ""},
+		{"CDELT2A", 0.25, ""},
+	}
+	hdu := &ImageHDU{header: NewHeader(cards, IMAGE_HDU, -64, []int64{10, 10})}
+
+	w, err := hdu.WCS("")
+	if err != nil {
+		t.Fatalf("error parsing WCS: %v", err)
+	}
+	cd := [][]float64{
+		{-0.1 * math.Cos(math.Pi/6), -0.1 * math.Sin(math.Pi/6)},
+		{-0.1 * math.Sin(math.Pi/6), 0.1 * math.Cos(math.Pi/6)},
+	}
+	for i := range cd {
+		if !wcsClose(w.CD[i], cd[i], 1e-12) {
+			t.Fatalf("expected CD=%v. got %v", cd, w.CD)
+		}
+	}
+
+	a, err := hdu.WCS("A")
+	if err != nil {
+		t.Fatalf("error parsing WCS A: %v", err)
+	}
+	if a.Name != "DETECTOR" || a.CUnit[0] != "mm" {
+		t.Fatalf("invalid WCS A: %#v", a)
+	}
+	world, err := a.PixelToWorld([]float64{4, 8})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	if !wcsClose(world, []float64{102, 202}, 1e-12) {
+		t.Fatalf("expected world=[102 202]. got %v", world)
+	}
+
+	_, err = hdu.WCS("B")
+	if err == nil {
+		t.Fatalf("expected an error for a missing alternate WCS")
+	}
+}
+
+func TestWCSColumn(t *testing.T) {
+	cards := []Card{
+		{"1CTYP2", "RA---TAN", ""},
+		{"2CTYP2", "DEC--TAN", ""},
+		{"1CRVL2", 83.6, ""},
+		{"2CRVL2", 22.0, ""},
+		{"1CRPX2", 16.5, ""},
+		{"2CRPX2", 16.5, ""},
+		{"11CD2", -0.001, ""},
+		{"22CD2", 0.001, ""},
+		{"1CTYP2B", "X", ""},
+		{"1CDLT2B", 2.0, ""},
+		{"1CTYP3", "WAVE", ""},
+		{"1CRVL3", 5000.0, ""},
+		{"1CRPX3", 1.0, ""},
+		{"1CDLT3", 2.0, ""},
+		{"WCAX4", int64(2), ""},
+		{"2CDLT4", 3.0, ""},
+	}
+	hdu := &Table{
+		header: NewHeader(cards, BINARY_TBL, 8, nil),
+		cols: []Column{
+			{Name: "TIME", Format: "D"},
+			{Name: "IMAGE", Format: "1024E", Dim: []int64{32, 32}},
+			{Name: "SPEC", Format: "64E"},
+			{Name: "FLAT", Format: "1024E"},
+		},
+	}
+
+	w, err := hdu.ColumnWCS("IMAGE", "")
+	if err != nil {
+		t.Fatalf("error parsing column WCS: %v", err)
+	}
+	world, err := w.PixelToWorld([]float64{15.5, 15.5})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	if !wcsClose(world, []float64{83.6, 22}, 1e-9) {
+		t.Fatalf("expected world=[83.6 22]. got %v", world)
+	}
+
+	b, err := hdu.ColumnWCS("IMAGE", "B")
+	if err != nil {
+		t.Fatalf("error parsing column WCS B: %v", err)
+	}
+	if b.CType[0] != "X" || b.CD[0][0] != 2 || b.CD[1][1] != 1 {
+		t.Fatalf("invalid column WCS B: %#v", b)
+	}
+
+	// image columns without TDIM
+	spec, err := hdu.ColumnWCS("SPEC", "")
+	if err != nil {
+		t.Fatalf("error parsing column WCS without TDIM: %v", err)
+	}
+	world, err = spec.PixelToWorld([]float64{10})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	if !wcsClose(world, []float64{5020}, 1e-9) {
+		t.Fatalf("expected world=[5020]. got %v", world)
+	}
+
+	flat, err := hdu.ColumnWCS("FLAT", "")
+	if err != nil {
+		t.Fatalf("error parsing column WCS with WCAXn: %v", err)
+	}
+	if len(flat.CType) != 2 || flat.CD[1][1] != 3 {
+		t.Fatalf("invalid column WCS with WCAXn: %#v", flat)
+	}
+
+	_, err = hdu.ColumnWCS("TIME", "")
+	if err == nil {
+		t.Fatalf("expected an error for a scalar column")
+	}
+	_, err = hdu.ColumnWCS("NONE", "")
+	if err == nil {
+		t.Fatalf("expected an error for a missing column")
+	}
+}
+
+func TestWCSImageFile(t *testing.T) {
+	f, done := newTestFile(t)
+	defer done()
+
+	cards := []Card{
+		{"CTYPE1", "GLON-ZEA", ""},
+		{"CTYPE2", "GLAT-ZEA", ""},
+		{"CRVAL1", 120.0, ""},
+		{"CRVAL2", -10.0, ""},
+		{"CRPIX1", 8.0, ""},
+		{"CRPIX2", 8.0, ""},
+		{"CDELT1", -0.5, ""},
+		{"CDELT2", 0.5, ""},
+	}
+	_, err := NewImageHDU(f, NewHeader(cards, IMAGE_HDU, -32, []int64{16, 16}))
+	if err != nil {
+		t.Fatalf("error creating image: %v", err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatalf("error closing file: %v", err)
+	}
+
+	*f, err = Open("new.fits", ReadOnly)
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+
+	hdu := f.HDU(1).(*ImageHDU)
+	world, err := hdu.PixelToWorld([]float64{7, 7})
+	if err != nil {
+		t.Fatalf("error converting pixel: %v", err)
+	}
+	if !wcsClose(world, []float64{120, -10}, 1e-9) {
+		t.Fatalf("expected world=[120 -10]. got %v", world)
+	}
+}
+
+// EOF